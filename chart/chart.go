@@ -0,0 +1,272 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package chart
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/rs/zerolog/log"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// MetadataFile is the name of the file holding the chart metadata, which
+	// identifies a directory as a chart.
+	MetadataFile = "chart.yaml"
+
+	// TemplatesDir is the directory within the chart holding the job template
+	// and any partials.
+	TemplatesDir = "templates"
+
+	// ValuesFile is the name of the file holding the default variable values.
+	ValuesFile = "values.yaml"
+
+	// ArchiveExtension is the file extension of packaged charts.
+	ArchiveExtension = ".tgz"
+
+	jobTemplateExtension = ".nomad"
+)
+
+// schemaFiles are the file names checked, in order, for the chart variable
+// schema.
+var schemaFiles = []string{"schema.yaml", "schema.yml", "schema.json", "schema.tf"}
+
+// Metadata describes a chart.
+type Metadata struct {
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// Chart is a packaged job template along with its default values, variable
+// schema and partials.
+type Chart struct {
+	Metadata *Metadata
+
+	// Dir is the root directory of the chart on disk.
+	Dir string
+
+	// TemplateFile is the job template within the templates directory.
+	TemplateFile string
+
+	// ValuesFile and SchemaFile are the paths of the default values and the
+	// variable schema, and are empty if the chart does not include them.
+	ValuesFile string
+	SchemaFile string
+
+	// tmpDir is the directory a chart archive was extracted to, which is
+	// removed when the chart is closed.
+	tmpDir string
+}
+
+// IsChart returns whether the passed path is a chart directory or a chart
+// archive.
+func IsChart(p string) bool {
+	if p == "" {
+		return false
+	}
+	if strings.HasSuffix(p, ArchiveExtension) || strings.HasSuffix(p, ".tar.gz") {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(p, MetadataFile))
+	return err == nil
+}
+
+// Load reads the chart from the passed directory or archive. Archives are
+// extracted to a temporary directory which is removed by calling Close.
+func Load(p string) (*Chart, error) {
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return loadDir(p)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "levant-chart-")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := extractArchive(p, tmpDir); err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, fmt.Errorf("unable to extract chart archive %s: %v", p, err)
+	}
+
+	// Archives hold the chart within a directory named after the chart, but
+	// also allow the chart files to be at the root of the archive.
+	dir := tmpDir
+	if _, err := os.Stat(filepath.Join(dir, MetadataFile)); err != nil {
+		entries, err := os.ReadDir(tmpDir)
+		if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+			os.RemoveAll(tmpDir)
+			return nil, fmt.Errorf("chart archive %s does not contain a %s file", p, MetadataFile)
+		}
+		dir = filepath.Join(tmpDir, entries[0].Name())
+	}
+
+	c, err := loadDir(dir)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, err
+	}
+	c.tmpDir = tmpDir
+
+	return c, nil
+}
+
+// loadDir reads the chart from the passed directory.
+func loadDir(dir string) (*Chart, error) {
+
+	meta, err := readMetadata(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Chart{Metadata: meta, Dir: dir}
+
+	templates, err := filepath.Glob(filepath.Join(dir, TemplatesDir, "*"+jobTemplateExtension))
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) != 1 {
+		return nil, fmt.Errorf("chart %s must contain a single %s job template within the %s directory, found %v",
+			meta.Name, jobTemplateExtension, TemplatesDir, len(templates))
+	}
+	c.TemplateFile = templates[0]
+
+	if _, err := os.Stat(filepath.Join(dir, ValuesFile)); err == nil {
+		c.ValuesFile = filepath.Join(dir, ValuesFile)
+	}
+
+	for _, f := range schemaFiles {
+		if _, err := os.Stat(filepath.Join(dir, f)); err == nil {
+			c.SchemaFile = filepath.Join(dir, f)
+			break
+		}
+	}
+
+	log.Debug().Msgf("chart/chart: loaded chart %s version %s from %s", meta.Name, meta.Version, dir)
+
+	return c, nil
+}
+
+// readMetadata reads and validates the chart metadata file.
+func readMetadata(dir string) (*Metadata, error) {
+
+	src, err := os.ReadFile(filepath.Join(dir, MetadataFile))
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &Metadata{}
+	if err := yaml.Unmarshal(src, meta); err != nil {
+		return nil, fmt.Errorf("unable to parse chart metadata: %v", err)
+	}
+
+	if meta.Name == "" {
+		return nil, fmt.Errorf("chart metadata must include a name")
+	}
+	if _, err := semver.StrictNewVersion(meta.Version); err != nil {
+		return nil, fmt.Errorf("chart %s version %q must be a valid semantic version", meta.Name, meta.Version)
+	}
+	return meta, nil
+}
+
+// Apply configures the template configuration to render the chart. The chart
+// default values are layered beneath any user variable files, and the chart
+// schema is used unless one has been passed.
+func (c *Chart) Apply(config *structs.TemplateConfig) {
+
+	config.TemplateFile = c.TemplateFile
+	config.TemplatePaths = append([]string{filepath.Join(c.Dir, TemplatesDir)}, config.TemplatePaths...)
+
+	if c.ValuesFile != "" {
+		config.VariableFiles = append([]string{c.ValuesFile}, config.VariableFiles...)
+	}
+
+	if config.VariableSchemaFile == "" {
+		config.VariableSchemaFile = c.SchemaFile
+	}
+}
+
+// Close removes any temporary files created when loading the chart.
+func (c *Chart) Close() error {
+	if c.tmpDir == "" {
+		return nil
+	}
+	return os.RemoveAll(c.tmpDir)
+}
+
+// extractArchive extracts the gzipped tar archive into the passed directory.
+func extractArchive(archive, dst string) error {
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Ensure entries cannot be written outside of the destination. Archives
+		// created from within the chart directory include an entry for the
+		// root, such as "./", which is the destination itself.
+		target := filepath.Join(dst, filepath.FromSlash(hdr.Name))
+		if target == filepath.Clean(dst) && hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if !strings.HasPrefix(target, filepath.Clean(dst)+string(os.PathSeparator)) {
+			return fmt.Errorf("archive entry %s is outside of the chart", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("archive entry %s has unsupported type", hdr.Name)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package chart
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/hashicorp/levant/template"
+)
+
+func TestChart_IsChart(t *testing.T) {
+
+	cases := map[string]bool{
+		"":                           false,
+		"test-fixtures/example":      true,
+		"test-fixtures":              false,
+		"example-1.2.0.tgz":          true,
+		"example-1.2.0.tar.gz":       true,
+		"test-fixtures/example.yaml": false,
+	}
+
+	for p, expected := range cases {
+		if actual := IsChart(p); actual != expected {
+			t.Fatalf("expected IsChart(%q) to be %v but got %v", p, expected, actual)
+		}
+	}
+}
+
+func TestChart_Load(t *testing.T) {
+
+	c, err := Load("test-fixtures/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if c.Metadata.Name != "example" || c.Metadata.Version != "1.2.0" {
+		t.Fatalf("unexpected chart metadata %+v", c.Metadata)
+	}
+	if c.TemplateFile != filepath.Join("test-fixtures/example", TemplatesDir, "example.nomad") {
+		t.Fatalf("unexpected template file %s", c.TemplateFile)
+	}
+	if c.ValuesFile != filepath.Join("test-fixtures/example", ValuesFile) {
+		t.Fatalf("unexpected values file %s", c.ValuesFile)
+	}
+	if c.SchemaFile != filepath.Join("test-fixtures/example", "schema.yaml") {
+		t.Fatalf("unexpected schema file %s", c.SchemaFile)
+	}
+
+	// Charts without a valid version are rejected.
+	if _, err := readMetadata("test-fixtures"); err == nil {
+		t.Fatal("expected error loading a directory without chart metadata")
+	}
+}
+
+func TestChart_PackageRender(t *testing.T) {
+
+	archive, err := Package("test-fixtures/example", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(archive) != "example-1.2.0.tgz" {
+		t.Fatalf("unexpected archive name %s", archive)
+	}
+
+	c, err := Load(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &structs.TemplateConfig{
+		ListMergeStrategy: "replace",
+		VariableFiles:     []string{"test-fixtures/values-override.yaml"},
+	}
+	c.Apply(config)
+
+	if config.VariableFiles[0] != c.ValuesFile {
+		t.Fatalf("expected chart values to be the first variable file but got %v", config.VariableFiles)
+	}
+
+	fVars := make(map[string]interface{})
+	job, err := template.RenderJobWithConfig(config, &structs.ClientConfig{}, &fVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *job.Name != "example" {
+		t.Fatalf("expected job name example but got %s", *job.Name)
+	}
+	if *job.TaskGroups[0].Count != 3 {
+		t.Fatalf("expected overridden count of 3 but got %v", *job.TaskGroups[0].Count)
+	}
+	if len(job.TaskGroups[0].Tasks) != 1 || job.TaskGroups[0].Tasks[0].Name != "redis" {
+		t.Fatalf("expected the redis task from the chart partial")
+	}
+
+	tmpDir := c.tmpDir
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(tmpDir); !os.IsNotExist(err) {
+		t.Fatalf("expected extracted chart %s to be removed", tmpDir)
+	}
+}
+
+func TestChart_LoadRootArchive(t *testing.T) {
+
+	// Build the archive in the same way as "tar -C example -czf example.tgz .",
+	// which includes a "./" entry for the chart root.
+	archive := filepath.Join(t.TempDir(), "example.tgz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	root := "test-fixtures/example"
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		name := "./" + filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel == "." {
+				name = "./"
+			}
+			return tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeDir, Mode: 0755})
+		}
+
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(b))}); err != nil {
+			return err
+		}
+		_, err = tw.Write(b)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []interface{ Close() error }{tw, gz, f} {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := Load(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if c.Metadata.Name != "example" || c.TemplateFile != filepath.Join(c.Dir, TemplatesDir, "example.nomad") {
+		t.Fatalf("unexpected chart loaded from root archive %+v", c)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package chart
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Package validates the chart within the passed directory and writes it to a
+// versioned archive named <name>-<version>.tgz within the output directory.
+// The archive holds the chart within a directory named after the chart.
+// Hidden files and directories, and existing archives, are not included.
+func Package(dir, outDir string) (string, error) {
+
+	c, err := loadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return "", err
+	}
+
+	archive := filepath.Join(outDir, fmt.Sprintf("%s-%s%s", c.Metadata.Name, c.Metadata.Version, ArchiveExtension))
+
+	f, err := os.Create(archive)
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeArchive(f, dir, c.Metadata.Name); err != nil {
+		f.Close()
+		os.Remove(archive)
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return archive, nil
+}
+
+// writeArchive writes the contents of the directory as a gzipped tar archive,
+// with all entries placed under the passed prefix directory. Chart archives
+// within the directory are skipped, allowing charts to be packaged in place.
+func writeArchive(w io.Writer, dir, prefix string) error {
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ArchiveExtension) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		if rel != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return fmt.Errorf("chart file %s is not a regular file", rel)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = path.Join(prefix, filepath.ToSlash(rel))
+		if d.IsDir() {
+			hdr.Name += "/"
+		}
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
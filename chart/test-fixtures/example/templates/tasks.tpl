@@ -0,0 +1,11 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: MPL-2.0
+
+[[- define "redis_task" ]]
+task "redis" {
+  driver = "docker"
+  config {
+    image = "redis:7"
+  }
+}
+[[- end ]]
@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"github.com/hashicorp/levant/chart"
+	"github.com/hashicorp/levant/levant/structs"
+)
+
+// loadChart configures the template configuration to render a chart when the
+// TEMPLATE argument is a chart directory or archive. The returned function
+// removes any files extracted from the chart and should be called once the
+// command has finished with the template.
+func loadChart(config *structs.TemplateConfig) (func(), error) {
+
+	if !chart.IsChart(config.TemplateFile) {
+		return func() {}, nil
+	}
+
+	c, err := chart.Load(config.TemplateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Apply(config)
+
+	return func() { c.Close() }, nil
+}
@@ -32,10 +32,15 @@ Usage: levant deploy [options] [TEMPLATE]
   via the command line take precedence over the same variable declared within
   a passed variable file.
 
+  Command line variables are strings unless a type is given in the format of
+  -var 'key:type=value', where type is one of bool, float, int, json or
+  string. JSON values can also be passed using -var-json 'key=<json>'.
+
 Arguments:
 
   TEMPLATE nomad job template
     If no argument is given we look for a single *.nomad file
+    A chart directory or packaged chart archive can also be given
 
 General Options:
 
@@ -46,14 +51,29 @@ General Options:
   -allow-stale
     Allow stale consistency mode for requests into nomad.
 
+  -blocked-eval-timeout=<seconds>
+    The time in seconds Levant will wait for a blocked evaluation to be
+    resolved before failing the deployment. Can only be used alongside the
+    -strict-placement flag. The default is 0 which fails immediately.
+
   -canary-auto-promote=<seconds>
     The time in seconds, after which Levant will auto-promote a canary job
     if all canaries within the deployment are healthy.
 
+  -capacity-check
+    Run a Nomad plan before registering the job to check the cluster has the
+    capacity to place it, including any canary allocations. If the job would
+    be blocked waiting for capacity, Levant will exit with a capacity report
+    instead of registering the job.
+
   -consul-address=<addr>
     The Consul host and port to use when making Consul KeyValue lookups for
     template rendering.
 
+  -consul-healthy-timeout=<seconds>
+    The time in seconds Levant will wait for job services to become healthy
+    when using the -wait-consul-healthy flag. The default is 300.
+
   -force
     Execute deployment even though there were no changes.
 
@@ -71,6 +91,27 @@ General Options:
     can be changed using this flag so that Levant will exit cleanly ensuring CD
     pipelines don't fail when no changes are detected.
 
+  -job-format=<format>
+    The format of the rendered job specification. Valid values are hcl and
+    json, where json is the Nomad API JSON format with or without the Job
+    wrapper. If not set, the format is detected from the rendered template.
+
+  -list-merge-strategy=<strategy>
+    How lists are merged when the same variable is declared in multiple
+    variable files. Valid values are replace, where the later list replaces
+    the earlier, and append. Maps are always merged recursively. The default
+    is replace.
+
+  -lock-file=<file>
+    The path of the lockfile used by -lock and -locked. The default is
+    levant.lock.
+
+  -locked
+    Replay the external lookups recorded in the lockfile rather than using
+    the current upstream values, so the job renders identically to when the
+    lockfile was written. Lookups which have changed upstream are reported as
+    warnings. Sensitive values are never recorded, so are always looked up.
+
   -log-level=<level>
     Specify the verbosity level of Levant's logs. Valid values include DEBUG,
     INFO, and WARN, in decreasing order of verbosity. The default is INFO.
@@ -79,11 +120,47 @@ General Options:
     Specify the format of Levant's logs. Valid values are HUMAN or JSON. The
     default is HUMAN.
 
+  -sensitive-pattern=<regex>
+    A regular expression used to identify sensitive variable names, in
+    addition to the default which matches names containing password, secret,
+    token, api_key, private_key or credential. The values of sensitive
+    variables are masked in all log output. Can be repeated.
+
+  -strict-placement
+    Treat any failed task group placement within the job evaluation as a
+    deployment failure. The full placement metrics reported by the Nomad
+    scheduler will be logged to help identify the cause.
+
+  -strict-vars
+    Fail rendering when the template references a variable which has not been
+    set, rather than rendering an empty value.
+
+  -template-path=<path>
+    A directory containing partial templates, or a glob pattern matching
+    them, which are loaded alongside the job template. All *.tpl files within
+    a directory are loaded. Templates defined by partials can be used with the
+    template action or the include and tpl functions. Can be repeated.
+
   -var-file=<file>
     Path to a file containing user variables used when rendering the job
     template. You can repeat this flag multiple times to supply multiple
-    var-files. Defaults to levant.(json|yaml|yml|tf).
+    var-files. Variables can also be loaded from a Nomad Variable using
+    nomadvar://<path>, a Consul KV prefix using consul://kv/<prefix> or over
+    HTTP(S) with an optional #sha256=<checksum> pin.
+    Defaults to levant.(json|yaml|yml|tf).
     [default: levant.(json|yaml|yml|tf)]
+
+  -var-schema=<file>
+    Path to a YAML, JSON or Terraform file declaring the variables the
+    template expects, including their type, default, description and
+    validation rules. The merged variables are validated against the
+    declarations before rendering, with all failures reported together.
+
+  -wait-consul-healthy
+    After the Nomad deployment checks have passed, wait for every service
+    defined within the job to be registered and passing its health checks for
+    all running allocations. Services using the Nomad provider are checked
+    using Nomad native service discovery, all others using Consul.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -111,16 +188,29 @@ func (c *DeployCommand) Run(args []string) int {
 
 	flags.StringVar(&config.Client.Addr, "address", "", "")
 	flags.BoolVar(&config.Client.AllowStale, "allow-stale", false, "")
+	flags.IntVar(&config.Deploy.BlockedEvalTimeout, "blocked-eval-timeout", 0, "")
 	flags.IntVar(&config.Deploy.Canary, "canary-auto-promote", 0, "")
+	flags.BoolVar(&config.Deploy.CapacityCheck, "capacity-check", false, "")
 	flags.StringVar(&config.Client.ConsulAddr, "consul-address", "", "")
+	flags.IntVar(&config.Deploy.ConsulHealthyTimeout, "consul-healthy-timeout", 300, "")
 	flags.BoolVar(&config.Deploy.Force, "force", false, "")
 	flags.BoolVar(&config.Deploy.ForceBatch, "force-batch", false, "")
 	flags.BoolVar(&config.Deploy.ForceCount, "force-count", false, "")
 	flags.BoolVar(&config.Plan.IgnoreNoChanges, "ignore-no-changes", false, "")
+	flags.StringVar(&config.Template.JobFormat, "job-format", "", "")
+	flags.StringVar(&config.Template.ListMergeStrategy, "list-merge-strategy", "replace", "")
+	flags.StringVar(&config.Template.LockFile, "lock-file", template.DefaultLockFile, "")
+	flags.BoolVar(&config.Template.Locked, "locked", false, "")
 	flags.StringVar(&level, "log-level", "INFO", "")
 	flags.StringVar(&format, "log-format", "HUMAN", "")
+	flags.BoolVar(&config.Deploy.WaitConsulHealthy, "wait-consul-healthy", false, "")
 
+	flags.Var((*helper.FlagStringSlice)(&config.Template.SensitivePatterns), "sensitive-pattern", "")
+	flags.BoolVar(&config.Deploy.StrictPlacement, "strict-placement", false, "")
+	flags.BoolVar(&config.Template.StrictVariables, "strict-vars", false, "")
+	flags.Var((*helper.FlagStringSlice)(&config.Template.TemplatePaths), "template-path", "")
 	flags.Var((*helper.FlagStringSlice)(&config.Template.VariableFiles), "var-file", "")
+	flags.StringVar(&config.Template.VariableSchemaFile, "var-schema", "", "")
 
 	if err = flags.Parse(args); err != nil {
 		return 1
@@ -146,8 +236,14 @@ func (c *DeployCommand) Run(args []string) int {
 		return 1
 	}
 
-	config.Template.Job, err = template.RenderJob(config.Template.TemplateFile,
-		config.Template.VariableFiles, config.Client.ConsulAddr, &c.Meta.flagVars)
+	closeChart, err := loadChart(config.Template)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+	defer closeChart()
+
+	config.Template.Job, err = template.RenderJobWithConfig(config.Template, config.Client, &c.Meta.flagVars)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
 		return 1
@@ -160,6 +256,11 @@ func (c *DeployCommand) Run(args []string) int {
 		}
 	}
 
+	if config.Deploy.BlockedEvalTimeout > 0 && !config.Deploy.StrictPlacement {
+		c.UI.Error("[ERROR] levant/command: blocked-eval-timeout passed but strict-placement is not enabled")
+		return 1
+	}
+
 	if config.Deploy.ForceBatch {
 		if err = c.checkForceBatch(config.Template.Job, config.Deploy.ForceBatch); err != nil {
 			c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
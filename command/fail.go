@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"strings"
+
+	"github.com/hashicorp/levant/levant"
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/hashicorp/levant/logging"
+)
+
+// FailCommand is the command implementation that allows users to manually
+// fail a running deployment.
+type FailCommand struct {
+	Meta
+}
+
+// Help provides the help information for the fail command.
+func (c *FailCommand) Help() string {
+	helpText := `
+Usage: levant fail [options] <job|deployment-id>
+
+  Mark the latest deployment of a job, or the deployment with the passed ID or
+  ID prefix, as failed. If any task group within the deployment is configured
+  to auto-revert, Nomad reverts the job to its latest stable version and the
+  resulting deployment is watched until it completes. The command fails if
+  the revert deployment does not succeed.
+
+Arguments:
+
+  JOB|DEPLOYMENT-ID  The ID of a job or deployment to fail.
+
+General Options:
+
+  -address=<http_address>
+    The Nomad HTTP API address including port which Levant will use to make
+    calls.
+
+  -allow-stale
+    Allow stale consistency mode for requests into nomad.
+
+  -log-level=<level>
+    Specify the verbosity level of Levant's logs. Valid values include DEBUG,
+    INFO, and WARN, in decreasing order of verbosity. The default is INFO.
+
+  -log-format=<format>
+    Specify the format of Levant's logs. Valid values are HUMAN or JSON. The
+    default is HUMAN.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis is provides a brief summary of the fail command.
+func (c *FailCommand) Synopsis() string {
+	return "Manually fail a running Nomad deployment"
+}
+
+// Run triggers a run of the Levant fail functions.
+func (c *FailCommand) Run(args []string) int {
+
+	var level, format string
+
+	config := &levant.DeployConfig{
+		Client: &structs.ClientConfig{},
+		Deploy: &structs.DeployConfig{},
+	}
+
+	flags := c.Meta.FlagSet("fail", FlagSetNone)
+	flags.Usage = func() { c.UI.Output(c.Help()) }
+
+	flags.StringVar(&config.Client.Addr, "address", "", "")
+	flags.BoolVar(&config.Client.AllowStale, "allow-stale", false, "")
+	flags.StringVar(&level, "log-level", "INFO", "")
+	flags.StringVar(&format, "log-format", "HUMAN", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	if err := logging.SetupLogger(level, format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if success := levant.TriggerFail(config, args[0], nil); !success {
+		return 1
+	}
+
+	return 0
+}
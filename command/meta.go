@@ -40,6 +40,7 @@ func (m *Meta) FlagSet(n string, fs FlagSetFlags) *flag.FlagSet {
 	// FlagSetVars tells us what variables to use
 	if fs&FlagSetVars != 0 {
 		f.Var((*helper.Flag)(&m.flagVars), "var", "")
+		f.Var((*helper.FlagJSON)(&m.flagVars), "var-json", "")
 	}
 
 	// Create an io.Writer that writes to our Ui properly for errors.
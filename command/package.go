@@ -0,0 +1,100 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/levant/chart"
+	"github.com/hashicorp/levant/logging"
+)
+
+// PackageCommand is the command implementation that packages a chart
+// directory into a versioned archive.
+type PackageCommand struct {
+	Meta
+}
+
+// Help provides the help information for the package command.
+func (c *PackageCommand) Help() string {
+	helpText := `
+Usage: levant package [options] [CHART]
+
+  Package a chart directory into a versioned archive named
+  <name>-<version>.tgz, using the name and version from the chart.yaml
+  metadata file. The chart is validated before being packaged. The archive
+  can be passed in place of a template to the deploy, plan, render and vars
+  commands.
+
+Arguments:
+
+  CHART chart directory
+    If no argument is given the current directory is used
+
+General Options:
+
+  -log-level=<level>
+    Specify the verbosity level of Levant's logs. Valid values include DEBUG,
+    INFO, and WARN, in decreasing order of verbosity. The default is INFO.
+
+  -log-format=<format>
+    Specify the format of Levant's logs. Valid values are HUMAN or JSON. The
+    default is HUMAN.
+
+  -out=<dir>
+    The directory to write the archive to. The default is the current
+    directory.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis is provides a brief summary of the package command.
+func (c *PackageCommand) Synopsis() string {
+	return "Package a chart into a versioned archive"
+}
+
+// Run triggers a run of the Levant package functions.
+func (c *PackageCommand) Run(args []string) int {
+
+	var err error
+	var level, format, outDir string
+
+	flags := c.Meta.FlagSet("package", FlagSetNone)
+	flags.Usage = func() { c.UI.Output(c.Help()) }
+
+	flags.StringVar(&level, "log-level", "INFO", "")
+	flags.StringVar(&format, "log-format", "HUMAN", "")
+	flags.StringVar(&outDir, "out", ".", "")
+
+	if err = flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+
+	if err = logging.SetupLogger(level, format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	dir := "."
+	switch len(args) {
+	case 0:
+	case 1:
+		dir = args[0]
+	default:
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	archive, err := chart.Package(dir, outDir)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+
+	c.UI.Output(fmt.Sprintf("Packaged chart to %s", archive))
+	return 0
+}
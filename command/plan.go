@@ -31,10 +31,15 @@ Usage: levant plan [options] [TEMPLATE]
   via the command line take precedence over the same variable declared within
   a passed variable file.
 
+  Command line variables are strings unless a type is given in the format of
+  -var 'key:type=value', where type is one of bool, float, int, json or
+  string. JSON values can also be passed using -var-json 'key=<json>'.
+
 Arguments:
 
   TEMPLATE nomad job template
     If no argument is given we look for a single *.nomad file
+    A chart directory or packaged chart archive can also be given
 
 General Options:
 
@@ -59,6 +64,27 @@ General Options:
     can be changed using this flag so that Levant will exit cleanly ensuring CD
     pipelines don't fail when no changes are detected.
 
+  -job-format=<format>
+    The format of the rendered job specification. Valid values are hcl and
+    json, where json is the Nomad API JSON format with or without the Job
+    wrapper. If not set, the format is detected from the rendered template.
+
+  -list-merge-strategy=<strategy>
+    How lists are merged when the same variable is declared in multiple
+    variable files. Valid values are replace, where the later list replaces
+    the earlier, and append. Maps are always merged recursively. The default
+    is replace.
+
+  -lock-file=<file>
+    The path of the lockfile used by -lock and -locked. The default is
+    levant.lock.
+
+  -locked
+    Replay the external lookups recorded in the lockfile rather than using
+    the current upstream values, so the job renders identically to when the
+    lockfile was written. Lookups which have changed upstream are reported as
+    warnings. Sensitive values are never recorded, so are always looked up.
+
   -log-level=<level>
     Specify the verbosity level of Levant's logs. Valid values include DEBUG,
     INFO, and WARN, in decreasing order of verbosity. The default is INFO.
@@ -67,11 +93,36 @@ General Options:
     Specify the format of Levant's logs. Valid values are HUMAN or JSON. The
     default is HUMAN.
 
+  -sensitive-pattern=<regex>
+    A regular expression used to identify sensitive variable names, in
+    addition to the default which matches names containing password, secret,
+    token, api_key, private_key or credential. The values of sensitive
+    variables are masked in all log output. Can be repeated.
+
+  -strict-vars
+    Fail rendering when the template references a variable which has not been
+    set, rather than rendering an empty value.
+
+  -template-path=<path>
+    A directory containing partial templates, or a glob pattern matching
+    them, which are loaded alongside the job template. All *.tpl files within
+    a directory are loaded. Templates defined by partials can be used with the
+    template action or the include and tpl functions. Can be repeated.
+
   -var-file=<file>
     Path to a file containing user variables used when rendering the job
     template. You can repeat this flag multiple times to supply multiple
-    var-files. Defaults to levant.(json|yaml|yml|tf).
+    var-files. Variables can also be loaded from a Nomad Variable using
+    nomadvar://<path>, a Consul KV prefix using consul://kv/<prefix> or over
+    HTTP(S) with an optional #sha256=<checksum> pin.
+    Defaults to levant.(json|yaml|yml|tf).
     [default: levant.(json|yaml|yml|tf)]
+
+  -var-schema=<file>
+    Path to a YAML, JSON or Terraform file declaring the variables the
+    template expects, including their type, default, description and
+    validation rules. The merged variables are validated against the
+    declarations before rendering, with all failures reported together.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -99,9 +150,17 @@ func (c *PlanCommand) Run(args []string) int {
 	flags.BoolVar(&config.Client.AllowStale, "allow-stale", false, "")
 	flags.StringVar(&config.Client.ConsulAddr, "consul-address", "", "")
 	flags.BoolVar(&config.Plan.IgnoreNoChanges, "ignore-no-changes", false, "")
+	flags.StringVar(&config.Template.JobFormat, "job-format", "", "")
+	flags.StringVar(&config.Template.ListMergeStrategy, "list-merge-strategy", "replace", "")
+	flags.StringVar(&config.Template.LockFile, "lock-file", template.DefaultLockFile, "")
+	flags.BoolVar(&config.Template.Locked, "locked", false, "")
 	flags.StringVar(&level, "log-level", "INFO", "")
 	flags.StringVar(&format, "log-format", "HUMAN", "")
+	flags.BoolVar(&config.Template.StrictVariables, "strict-vars", false, "")
+	flags.Var((*helper.FlagStringSlice)(&config.Template.SensitivePatterns), "sensitive-pattern", "")
+	flags.Var((*helper.FlagStringSlice)(&config.Template.TemplatePaths), "template-path", "")
 	flags.Var((*helper.FlagStringSlice)(&config.Template.VariableFiles), "var-file", "")
+	flags.StringVar(&config.Template.VariableSchemaFile, "var-schema", "", "")
 
 	if err = flags.Parse(args); err != nil {
 		return 1
@@ -127,8 +186,14 @@ func (c *PlanCommand) Run(args []string) int {
 		return 1
 	}
 
-	config.Template.Job, err = template.RenderJob(config.Template.TemplateFile,
-		config.Template.VariableFiles, config.Client.ConsulAddr, &c.Meta.flagVars)
+	closeChart, err := loadChart(config.Template)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+	defer closeChart()
+
+	config.Template.Job, err = template.RenderJobWithConfig(config.Template, config.Client, &c.Meta.flagVars)
 
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"strings"
+
+	"github.com/hashicorp/levant/helper"
+	"github.com/hashicorp/levant/levant"
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/hashicorp/levant/logging"
+)
+
+// PromoteCommand is the command implementation that allows users to promote
+// the canaries of a running deployment.
+type PromoteCommand struct {
+	Meta
+}
+
+// Help provides the help information for the promote command.
+func (c *PromoteCommand) Help() string {
+	helpText := `
+Usage: levant promote [options] <job|deployment-id>
+
+  Promote the canaries of the latest deployment of a job, or of the deployment
+  with the passed ID or ID prefix. Unless the -force flag is passed, the
+  canaries are checked to be healthy before promoting. Once promoted, the
+  deployment is watched until it completes in the same way as by the deploy
+  command, and the command exits with the same status codes. If -group is
+  passed while other task groups still have canaries awaiting promotion, the
+  command exits once the promoted task groups are healthy.
+
+Arguments:
+
+  JOB|DEPLOYMENT-ID  The ID of a job or deployment to promote.
+
+General Options:
+
+  -address=<http_address>
+    The Nomad HTTP API address including port which Levant will use to make
+    calls.
+
+  -allow-stale
+    Allow stale consistency mode for requests into nomad.
+
+  -force
+    Promote the canaries even if they are not all healthy.
+
+  -group=<name>
+    The name of a task group whose canaries should be promoted. Can be
+    repeated. If not passed, the canaries of all task groups are promoted.
+
+  -log-level=<level>
+    Specify the verbosity level of Levant's logs. Valid values include DEBUG,
+    INFO, and WARN, in decreasing order of verbosity. The default is INFO.
+
+  -log-format=<format>
+    Specify the format of Levant's logs. Valid values are HUMAN or JSON. The
+    default is HUMAN.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis is provides a brief summary of the promote command.
+func (c *PromoteCommand) Synopsis() string {
+	return "Promote the canaries of a Nomad deployment"
+}
+
+// Run triggers a run of the Levant promote functions.
+func (c *PromoteCommand) Run(args []string) int {
+
+	var force bool
+	var groups []string
+	var level, format string
+
+	config := &levant.DeployConfig{
+		Client: &structs.ClientConfig{},
+		Deploy: &structs.DeployConfig{},
+	}
+
+	flags := c.Meta.FlagSet("promote", FlagSetNone)
+	flags.Usage = func() { c.UI.Output(c.Help()) }
+
+	flags.StringVar(&config.Client.Addr, "address", "", "")
+	flags.BoolVar(&config.Client.AllowStale, "allow-stale", false, "")
+	flags.BoolVar(&force, "force", false, "")
+	flags.Var((*helper.FlagStringSlice)(&groups), "group", "")
+	flags.StringVar(&level, "log-level", "INFO", "")
+	flags.StringVar(&format, "log-format", "HUMAN", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	if err := logging.SetupLogger(level, format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if success := levant.TriggerPromote(config, args[0], groups, force, nil); !success {
+		return 1
+	}
+
+	return 0
+}
@@ -5,13 +5,24 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/hashicorp/levant/helper"
+	"github.com/hashicorp/levant/levant"
+	"github.com/hashicorp/levant/levant/structs"
 	"github.com/hashicorp/levant/logging"
 	"github.com/hashicorp/levant/template"
+	nomad "github.com/hashicorp/nomad/api"
+)
+
+const (
+	renderFormatAPIJSON = "api-json"
+	renderFormatHCL     = "hcl"
+	renderFormatJSON    = "json"
 )
 
 // RenderCommand is the command implementation that allows users to render a
@@ -31,17 +42,68 @@ Usage: levant render [options] [TEMPLATE]
   passed via the command line take precedence over the same variable declared
   within a passed variable file.
 
+  Command line variables are strings unless a type is given in the format of
+  -var 'key:type=value', where type is one of bool, float, int, json or
+  string. JSON values can also be passed using -var-json 'key=<json>'.
+
 Arguments:
 
   TEMPLATE  nomad job template
     If no argument is given we look for a single *.nomad file
+    A chart directory or packaged chart archive can also be given
 
 General Options:
 
+  -address=<http_address>
+    The Nomad HTTP API address including port which Levant will use to make
+    calls when rendering Nomad service and variable template functions.
+
   -consul-address=<addr>
     The Consul host and port to use when making Consul KeyValue lookups for
     template rendering.
 
+  -consul-fixture=<file>
+    A YAML or JSON file of Consul KV keys and values used in place of Consul
+    when rendering with -offline. Nested maps are joined using "/" to build
+    the full key.
+
+  -diff
+    Output a diff between the rendered job and the version of the job
+    currently registered with the Nomad cluster, rather than the rendered
+    job. Both jobs are compared in the canonical JSON format.
+
+  -format=<format>
+    The output format of the rendered job. Valid values are hcl, which
+    formats the rendered HCL job, json, which outputs the parsed job with all
+    defaults set, and api-json, which outputs the parsed job wrapped for the
+    Nomad jobs API. If not set, the rendered template is output unchanged.
+
+  -job-format=<format>
+    The format of the rendered job specification. Valid values are hcl and
+    json, where json is the Nomad API JSON format with or without the Job
+    wrapper. If not set, the format is detected from the rendered template.
+
+  -list-merge-strategy=<strategy>
+    How lists are merged when the same variable is declared in multiple
+    variable files. Valid values are replace, where the later list replaces
+    the earlier, and append. Maps are always merged recursively. The default
+    is replace.
+
+  -lock
+    Record the result of every external lookup made while rendering, such as
+    Consul KV keys, environment variables, files and the current time, to the
+    lockfile so that the render can be reproduced using -locked.
+
+  -lock-file=<file>
+    The path of the lockfile used by -lock and -locked. The default is
+    levant.lock.
+
+  -locked
+    Replay the external lookups recorded in the lockfile rather than using
+    the current upstream values, so the job renders identically to when the
+    lockfile was written. Lookups which have changed upstream are reported as
+    warnings. Sensitive values are never recorded, so are always looked up.
+
   -log-level=<level>
     Specify the verbosity level of Levant's logs. Valid values include DEBUG,
     INFO, and WARN, in decreasing order of verbosity. The default is INFO.
@@ -50,14 +112,45 @@ General Options:
     Specify the format of Levant's logs. Valid values are HUMAN or JSON. The
     default is HUMAN.
 
+  -offline
+    Render the template without contacting Consul, Nomad, Vault or HTTP
+    servers. Consul KV lookups use the -consul-fixture file if passed, while
+    all other external lookups fail. Environment variables and local files
+    are read as normal.
+
   -out=<file>
     Specify the path to write the rendered template out to, if a file exists at
     the specified path it will be truncated before rendering. The template will be
     rendered to stdout if this is not set.
 
+  -sensitive-pattern=<regex>
+    A regular expression used to identify sensitive variable names, in
+    addition to the default which matches names containing password, secret,
+    token, api_key, private_key or credential. The values of sensitive
+    variables are masked in all log output. Can be repeated.
+
+  -strict-vars
+    Fail rendering when the template references a variable which has not been
+    set, rather than rendering an empty value.
+
+  -template-path=<path>
+    A directory containing partial templates, or a glob pattern matching
+    them, which are loaded alongside the job template. All *.tpl files within
+    a directory are loaded. Templates defined by partials can be used with the
+    template action or the include and tpl functions. Can be repeated.
+
   -var-file=<file>
     The variables file to render the template with. You can repeat this flag multiple
-    times to supply multiple var-files. [default: levant.(json|yaml|yml|tf)]
+    times to supply multiple var-files. Variables can also be loaded from a Nomad
+    Variable using nomadvar://<path>, a Consul KV prefix using
+    consul://kv/<prefix> or over HTTP(S) with an optional #sha256=<checksum>
+    pin. [default: levant.(json|yaml|yml|tf)]
+
+  -var-schema=<file>
+    Path to a YAML, JSON or Terraform file declaring the variables the
+    template expects, including their type, default, description and
+    validation rules. The merged variables are validated against the
+    declarations before rendering, with all failures reported together.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -70,19 +163,37 @@ func (c *RenderCommand) Synopsis() string {
 // Run triggers a run of the Levant template functions.
 func (c *RenderCommand) Run(args []string) int {
 
-	var addr, outPath, templateFile string
-	var variables []string
+	var outPath, outFormat string
+	var diff bool
 	var err error
 	var tpl *bytes.Buffer
+	var job *nomad.Job
 	var level, format string
 
+	clientConfig := &structs.ClientConfig{}
+	config := &structs.TemplateConfig{}
+
 	flags := c.Meta.FlagSet("render", FlagSetVars)
 	flags.Usage = func() { c.UI.Output(c.Help()) }
 
-	flags.StringVar(&addr, "consul-address", "", "")
+	flags.StringVar(&clientConfig.Addr, "address", "", "")
+	flags.StringVar(&clientConfig.ConsulAddr, "consul-address", "", "")
+	flags.StringVar(&config.ConsulFixtureFile, "consul-fixture", "", "")
+	flags.BoolVar(&diff, "diff", false, "")
+	flags.StringVar(&outFormat, "format", "", "")
+	flags.StringVar(&config.JobFormat, "job-format", "", "")
+	flags.StringVar(&config.ListMergeStrategy, "list-merge-strategy", "replace", "")
+	flags.BoolVar(&config.Lock, "lock", false, "")
+	flags.StringVar(&config.LockFile, "lock-file", template.DefaultLockFile, "")
+	flags.BoolVar(&config.Locked, "locked", false, "")
 	flags.StringVar(&level, "log-level", "INFO", "")
 	flags.StringVar(&format, "log-format", "HUMAN", "")
-	flags.Var((*helper.FlagStringSlice)(&variables), "var-file", "")
+	flags.BoolVar(&config.Offline, "offline", false, "")
+	flags.BoolVar(&config.StrictVariables, "strict-vars", false, "")
+	flags.Var((*helper.FlagStringSlice)(&config.SensitivePatterns), "sensitive-pattern", "")
+	flags.Var((*helper.FlagStringSlice)(&config.TemplatePaths), "template-path", "")
+	flags.Var((*helper.FlagStringSlice)(&config.VariableFiles), "var-file", "")
+	flags.StringVar(&config.VariableSchemaFile, "var-schema", "", "")
 	flags.StringVar(&outPath, "out", "", "")
 
 	if err = flags.Parse(args); err != nil {
@@ -97,9 +208,9 @@ func (c *RenderCommand) Run(args []string) int {
 	}
 
 	if len(args) == 1 {
-		templateFile = args[0]
+		config.TemplateFile = args[0]
 	} else if len(args) == 0 {
-		if templateFile = helper.GetDefaultTmplFile(); templateFile == "" {
+		if config.TemplateFile = helper.GetDefaultTmplFile(); config.TemplateFile == "" {
 			c.UI.Error(c.Help())
 			c.UI.Error("\nERROR: Template arg missing and no default template found")
 			return 1
@@ -109,7 +220,47 @@ func (c *RenderCommand) Run(args []string) int {
 		return 1
 	}
 
-	tpl, err = template.RenderTemplate(templateFile, variables, addr, &c.Meta.flagVars)
+	switch outFormat {
+	case "", renderFormatAPIJSON, renderFormatHCL, renderFormatJSON:
+	default:
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: output format %q not supported", outFormat))
+		return 1
+	}
+
+	if diff && config.Offline {
+		c.UI.Error("[ERROR] levant/command: -diff cannot be used with -offline")
+		return 1
+	}
+
+	closeChart, err := loadChart(config)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+	defer closeChart()
+
+	// The job only needs parsing when it is formatted or diffed, which allows
+	// the raw template output to be used when debugging a template which does
+	// not parse.
+	if outFormat == "" && !diff {
+		tpl, err = template.RenderTemplateWithConfig(config, clientConfig, &c.Meta.flagVars)
+	} else {
+		tpl, job, err = template.RenderWithConfig(config, clientConfig, &c.Meta.flagVars)
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+
+	switch {
+	case diff:
+		var d string
+		if d, err = levant.RegisteredJobDiff(clientConfig, job); err == nil {
+			tpl = bytes.NewBufferString(d)
+		}
+	case outFormat != "":
+		tpl, err = formatRenderedJob(tpl, job, outFormat)
+	}
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
 		return 1
@@ -132,3 +283,31 @@ func (c *RenderCommand) Run(args []string) int {
 
 	return 0
 }
+
+// formatRenderedJob formats the rendered job in the requested output format.
+func formatRenderedJob(tpl *bytes.Buffer, job *nomad.Job, outFormat string) (*bytes.Buffer, error) {
+
+	var out []byte
+	var err error
+
+	switch outFormat {
+	case renderFormatHCL:
+		if json.Valid(tpl.Bytes()) {
+			return nil, fmt.Errorf("output format %q not supported for JSON jobs", outFormat)
+		}
+		out = hclwrite.Format(tpl.Bytes())
+	case renderFormatJSON:
+		job.Canonicalize()
+		out, err = json.MarshalIndent(job, "", "  ")
+	case renderFormatAPIJSON:
+		out, err = json.MarshalIndent(struct{ Job *nomad.Job }{Job: job}, "", "  ")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out) > 0 && out[len(out)-1] != '\n' {
+		out = append(out, '\n')
+	}
+	return bytes.NewBuffer(out), nil
+}
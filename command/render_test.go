@@ -0,0 +1,61 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/hashicorp/levant/template"
+	nomad "github.com/hashicorp/nomad/api"
+)
+
+func TestRender_formatRenderedJob(t *testing.T) {
+
+	fVars := make(map[string]interface{})
+	config := &structs.TemplateConfig{TemplateFile: "test-fixtures/job_canary.nomad"}
+
+	render := func() (*nomad.Job, string) {
+		tpl, job, err := template.RenderWithConfig(config, &structs.ClientConfig{}, &fVars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return job, tpl.String()
+	}
+
+	// The json format outputs the job with all defaults set.
+	job, tpl := render()
+	out, err := formatRenderedJob(bytes.NewBufferString(tpl), job, renderFormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonical := &nomad.Job{}
+	if err := json.Unmarshal(out.Bytes(), canonical); err != nil {
+		t.Fatal(err)
+	}
+	if canonical.Priority == nil || *canonical.Priority != 50 {
+		t.Fatalf("expected the default priority to be set but got %v", canonical.Priority)
+	}
+
+	// The api-json format wraps the job for the jobs API.
+	job, tpl = render()
+	if out, err = formatRenderedJob(bytes.NewBufferString(tpl), job, renderFormatAPIJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "{\n  \"Job\": {") {
+		t.Fatalf("expected the job to be wrapped but got %s", out.String())
+	}
+
+	// The hcl format formats the rendered template.
+	job, _ = render()
+	if out, err = formatRenderedJob(bytes.NewBufferString("job \"example\" {\ntype=\"service\"\n}\n"), job, renderFormatHCL); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "job \"example\" {\n  type = \"service\"\n}\n"; out.String() != expected {
+		t.Fatalf("expected %q but got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hashicorp/levant/levant"
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/hashicorp/levant/logging"
+)
+
+const (
+	statusFormatHuman = "human"
+	statusFormatJSON  = "json"
+)
+
+// StatusCommand is the command implementation that allows users to inspect
+// the current state of a job and its latest deployment.
+type StatusCommand struct {
+	Meta
+}
+
+// Help provides the help information for the status command.
+func (c *StatusCommand) Help() string {
+	helpText := `
+Usage: levant status [options] <job>
+
+  Display the current status of a job, including the state of its latest
+  deployment, the placement, health and canary counts of each task group, the
+  version history of the job and the reasons recent allocations failed. The
+  status command is read-only and does not modify the job.
+
+Arguments:
+
+  JOB  The ID of the job to display the status of.
+
+General Options:
+
+  -address=<http_address>
+    The Nomad HTTP API address including port which Levant will use to make
+    calls.
+
+  -allow-stale
+    Allow stale consistency mode for requests into nomad.
+
+  -format=<format>
+    The output format. Valid values are human and json. The default is human.
+
+  -log-level=<level>
+    Specify the verbosity level of Levant's logs. Valid values include DEBUG,
+    INFO, and WARN, in decreasing order of verbosity. The default is WARN.
+
+  -log-format=<format>
+    Specify the format of Levant's logs. Valid values are HUMAN or JSON. The
+    default is HUMAN.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis is provides a brief summary of the status command.
+func (c *StatusCommand) Synopsis() string {
+	return "Display the status of a job and its latest deployment"
+}
+
+// Run triggers a run of the Levant status functions.
+func (c *StatusCommand) Run(args []string) int {
+
+	var outFormat, level, format string
+	config := &structs.ClientConfig{}
+
+	flags := c.Meta.FlagSet("status", FlagSetNone)
+	flags.Usage = func() { c.UI.Output(c.Help()) }
+
+	flags.StringVar(&config.Addr, "address", "", "")
+	flags.BoolVar(&config.AllowStale, "allow-stale", false, "")
+	flags.StringVar(&outFormat, "format", statusFormatHuman, "")
+	flags.StringVar(&level, "log-level", "WARN", "")
+	flags.StringVar(&format, "log-format", "HUMAN", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	if err := logging.SetupLogger(level, format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if outFormat != statusFormatHuman && outFormat != statusFormatJSON {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: output format %q not supported", outFormat))
+		return 1
+	}
+
+	status, err := levant.GetJobStatus(config, args[0])
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+
+	out, err := formatJobStatus(status, outFormat)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+
+	c.UI.Output(out)
+	return 0
+}
+
+// formatJobStatus formats the job status in the requested output format.
+func formatJobStatus(status *levant.JobStatus, outFormat string) (string, error) {
+
+	if outFormat == statusFormatJSON {
+		out, err := json.MarshalIndent(status, "", "  ")
+		return string(out), err
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "ID\t= %s\n", status.ID)
+	fmt.Fprintf(w, "Namespace\t= %s\n", status.Namespace)
+	fmt.Fprintf(w, "Type\t= %s\n", status.Type)
+	fmt.Fprintf(w, "Status\t= %s\n", status.Status)
+	fmt.Fprintf(w, "Version\t= %v\n", status.Version)
+	fmt.Fprintf(w, "Stable\t= %v\n", status.Stable)
+
+	if dep := status.Deployment; dep != nil {
+		fmt.Fprintf(w, "\nLatest Deployment\n")
+		fmt.Fprintf(w, "ID\t= %s\n", dep.ID)
+		fmt.Fprintf(w, "Job Version\t= %v\n", dep.JobVersion)
+		fmt.Fprintf(w, "Status\t= %s\n", dep.Status)
+		fmt.Fprintf(w, "Description\t= %s\n", dep.Description)
+	}
+	_ = w.Flush()
+
+	if len(status.Groups) > 0 {
+		fmt.Fprintf(&buf, "\nTask Groups\n")
+		w = tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Name\tQueued\tStarting\tRunning\tFailed\tComplete\tLost\tDesired\tPlaced\tHealthy\tUnhealthy\tCanaries\tPromoted")
+		for _, g := range status.Groups {
+			deployment := "-\t-\t-\t-\t-\t-"
+			if g.InLastDeployment {
+				deployment = fmt.Sprintf("%v\t%v\t%v\t%v\t%v/%v\t%v",
+					g.Desired, g.Placed, g.Healthy, g.Unhealthy, g.PlacedCanaries, g.DesiredCanaries, g.Promoted)
+			}
+			fmt.Fprintf(w, "%s\t%v\t%v\t%v\t%v\t%v\t%v\t%s\n",
+				g.Name, g.Queued, g.Starting, g.Running, g.Failed, g.Complete, g.Lost, deployment)
+		}
+		_ = w.Flush()
+	}
+
+	if len(status.Versions) > 0 {
+		fmt.Fprintf(&buf, "\nVersions\n")
+		w = tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Version\tStable\tSubmitted")
+		for _, v := range status.Versions {
+			fmt.Fprintf(w, "%v\t%v\t%s\n", v.Version, v.Stable, v.Submitted.UTC().Format(time.RFC3339))
+		}
+		_ = w.Flush()
+	}
+
+	if len(status.FailedAllocations) > 0 {
+		fmt.Fprintf(&buf, "\nRecent Failed Allocations\n")
+		for _, a := range status.FailedAllocations {
+			fmt.Fprintf(&buf, "%s (group %s, failed %s)\n", a.ID, a.Group, a.Modified.UTC().Format(time.RFC3339))
+			for _, r := range a.Reasons {
+				fmt.Fprintf(&buf, "  - %s\n", r)
+			}
+		}
+	}
+
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
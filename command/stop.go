@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/levant/helper"
+	"github.com/hashicorp/levant/levant"
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/hashicorp/levant/logging"
+	"github.com/hashicorp/levant/template"
+	nomad "github.com/hashicorp/nomad/api"
+)
+
+// StopCommand is the command implementation that allows users to stop a
+// Nomad job.
+type StopCommand struct {
+	Meta
+}
+
+// Help provides the help information for the stop command.
+func (c *StopCommand) Help() string {
+	helpText := `
+Usage: levant stop [options] [JOB|TEMPLATE]
+
+  Stop a Nomad job by deregistering it. The resulting evaluation is inspected
+  and Levant waits for every allocation of the job to reach a terminal client
+  status, allowing tasks to drain according to their shutdown delay and kill
+  timeout.
+
+Arguments:
+
+  JOB|TEMPLATE  The ID of the job to stop, or a nomad job template
+    If the argument is an existing file or chart directory it is rendered to
+    find the job ID and namespace. If no argument is given we look for a
+    single *.nomad file
+
+General Options:
+
+  -address=<http_address>
+    The Nomad HTTP API address including port which Levant will use to make
+    calls.
+
+  -allow-stale
+    Allow stale consistency mode for requests into nomad.
+
+  -consul-address=<addr>
+    The Consul host and port to use when making Consul KeyValue lookups for
+    template rendering.
+
+  -global
+    Stop a multiregion job in all of its regions. By default the job is only
+    stopped in the targeted region.
+
+  -log-level=<level>
+    Specify the verbosity level of Levant's logs. Valid values include DEBUG,
+    INFO, and WARN, in decreasing order of verbosity. The default is INFO.
+
+  -log-format=<format>
+    Specify the format of Levant's logs. Valid values are HUMAN or JSON. The
+    default is HUMAN.
+
+  -purge
+    Purge the job from Nomad once it has been stopped, rather than leaving it
+    to be garbage collected.
+
+  -timeout=<seconds>
+    The time in seconds Levant will wait for all allocations of the job to
+    stop. The default is 300.
+
+  -var-file=<file>
+    Path to a file containing user variables used when rendering the job
+    template. You can repeat this flag multiple times to supply multiple
+    var-files.
+    [default: levant.(json|yaml|yml|tf)]
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis is provides a brief summary of the stop command.
+func (c *StopCommand) Synopsis() string {
+	return "Stop a Nomad job and wait for its allocations to stop"
+}
+
+// Run triggers a run of the Levant stop functions.
+func (c *StopCommand) Run(args []string) int {
+
+	var err error
+	var level, format string
+
+	config := &levant.StopConfig{
+		Client:   &structs.ClientConfig{},
+		Stop:     &structs.StopConfig{},
+		Template: &structs.TemplateConfig{},
+	}
+
+	flags := c.Meta.FlagSet("stop", FlagSetVars)
+	flags.Usage = func() { c.UI.Output(c.Help()) }
+
+	flags.StringVar(&config.Client.Addr, "address", "", "")
+	flags.BoolVar(&config.Client.AllowStale, "allow-stale", false, "")
+	flags.StringVar(&config.Client.ConsulAddr, "consul-address", "", "")
+	flags.BoolVar(&config.Stop.Global, "global", false, "")
+	flags.StringVar(&level, "log-level", "INFO", "")
+	flags.StringVar(&format, "log-format", "HUMAN", "")
+	flags.BoolVar(&config.Stop.Purge, "purge", false, "")
+	flags.IntVar(&config.Stop.Timeout, "timeout", 300, "")
+	flags.Var((*helper.FlagStringSlice)(&config.Template.VariableFiles), "var-file", "")
+
+	if err = flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+
+	if err = logging.SetupLogger(level, format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if len(args) == 1 {
+		if _, err = os.Stat(args[0]); err != nil {
+			config.Template.Job = &nomad.Job{ID: &args[0], Name: &args[0]}
+		} else {
+			config.Template.TemplateFile = args[0]
+		}
+	} else if len(args) == 0 {
+		if config.Template.TemplateFile = helper.GetDefaultTmplFile(); config.Template.TemplateFile == "" {
+			c.UI.Error(c.Help())
+			c.UI.Error("\nERROR: Job or template arg missing and no default template found")
+			return 1
+		}
+	} else {
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	if config.Stop.Timeout <= 0 {
+		c.UI.Error("[ERROR] levant/command: timeout must be greater than 0")
+		return 1
+	}
+
+	if config.Template.Job == nil {
+		closeChart, err := loadChart(config.Template)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+			return 1
+		}
+		defer closeChart()
+
+		config.Template.Job, err = template.RenderJobWithConfig(config.Template, config.Client, &c.Meta.flagVars)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+			return 1
+		}
+	}
+
+	success := levant.TriggerStop(config, nil)
+	if !success {
+		return 1
+	}
+
+	return 0
+}
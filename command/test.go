@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/levant/jobtest"
+	"github.com/hashicorp/levant/logging"
+)
+
+// TestCommand is the command implementation that runs the unit tests of job
+// templates.
+type TestCommand struct {
+	Meta
+}
+
+// Help provides the help information for the test command.
+func (c *TestCommand) Help() string {
+	helpText := `
+Usage: levant test [options] [PATH...]
+
+  Run the unit tests of Nomad job templates. Test files are named after the
+  template they test, such as example_test.hcl or example_test.yaml for the
+  example.nomad template, and are discovered recursively within each
+  directory passed. Each test renders the template with the test variables,
+  using fixed Consul KV, environment variable and file values in place of
+  external lookups so that no network calls are made, and checks assertions
+  such as TaskGroups[api].Count == 3 against the rendered job. The command
+  exits with a status of 1 if any test fails.
+
+Arguments:
+
+  PATH test file or directory containing test files
+    If no argument is given the current directory is searched
+
+General Options:
+
+  -log-level=<level>
+    Specify the verbosity level of Levant's logs. Valid values include DEBUG,
+    INFO, and WARN, in decreasing order of verbosity. The default is WARN.
+
+  -log-format=<format>
+    Specify the format of Levant's logs. Valid values are HUMAN or JSON. The
+    default is HUMAN.
+
+  -run=<regex>
+    Only run the tests whose names match the regular expression.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis is provides a brief summary of the test command.
+func (c *TestCommand) Synopsis() string {
+	return "Run the unit tests of job templates"
+}
+
+// Run triggers a run of the Levant test functions.
+func (c *TestCommand) Run(args []string) int {
+
+	var err error
+	var level, format, run string
+
+	flags := c.Meta.FlagSet("test", FlagSetNone)
+	flags.Usage = func() { c.UI.Output(c.Help()) }
+
+	flags.StringVar(&level, "log-level", "WARN", "")
+	flags.StringVar(&format, "log-format", "HUMAN", "")
+	flags.StringVar(&run, "run", "", "")
+
+	if err = flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+
+	if err = logging.SetupLogger(level, format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	var filter *regexp.Regexp
+	if run != "" {
+		if filter, err = regexp.Compile(run); err != nil {
+			c.UI.Error(fmt.Sprintf("[ERROR] levant/command: invalid -run expression: %v", err))
+			return 1
+		}
+	}
+
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	files, err := jobtest.Discover(args)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+
+	if len(files) == 0 {
+		c.UI.Output("No template test files found")
+		return 0
+	}
+
+	var total, failed int
+
+	for _, path := range files {
+		f, err := jobtest.ParseFile(path)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+			return 1
+		}
+
+		for _, r := range jobtest.Run(f, filter) {
+			total++
+			if r.Passed() {
+				c.UI.Output(fmt.Sprintf("--- PASS: %s: %s", r.File, r.Test))
+				continue
+			}
+
+			failed++
+			c.UI.Output(fmt.Sprintf("--- FAIL: %s: %s", r.File, r.Test))
+			for _, failure := range r.Failures {
+				c.UI.Output(fmt.Sprintf("    %s", failure))
+			}
+		}
+	}
+
+	if failed > 0 {
+		c.UI.Output(fmt.Sprintf("FAIL: %v of %v tests failed", failed, total))
+		return 1
+	}
+
+	c.UI.Output(fmt.Sprintf("PASS: %v tests passed", total))
+	return 0
+}
@@ -0,0 +1,345 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/levant/helper"
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/hashicorp/levant/lint"
+	"github.com/hashicorp/levant/logging"
+	"github.com/hashicorp/levant/template"
+)
+
+const (
+	validateFormatGitHub = "github"
+	validateFormatHuman  = "human"
+	validateFormatJSON   = "json"
+)
+
+// ValidateCommand is the command implementation that validates a job template
+// offline, running the built-in lint rules against the rendered job.
+type ValidateCommand struct {
+	Meta
+}
+
+// Help provides the help information for the validate command.
+func (c *ValidateCommand) Help() string {
+	helpText := `
+Usage: levant validate [options] [TEMPLATE]
+
+  Render and parse a Nomad job template locally, without needing a Nomad
+  cluster, and run the built-in lint rules against the job. The command exits
+  with a status of 1 if the template fails to render or parse, or if any
+  problem is found with a severity at or above the -fail-on severity.
+
+  Rules can be suppressed within the template using a comment such as
+  # levant:ignore latest-image-tag, which applies to the block on the
+  following line, or to the enclosing job, group or task block. Multiple rules
+  can be separated by commas, and all suppresses every rule.
+
+Rules:
+
+` + validateRulesHelp() + `
+Arguments:
+
+  TEMPLATE nomad job template
+    If no argument is given we look for a single *.nomad file
+    A chart directory or packaged chart archive can also be given
+
+General Options:
+
+  -address=<http_address>
+    The Nomad HTTP API address including port which Levant will use to make
+    calls when rendering Nomad service and variable template functions.
+
+  -consul-address=<addr>
+    The Consul host and port to use when making Consul KeyValue lookups for
+    template rendering.
+
+  -consul-fixture=<file>
+    A YAML or JSON file of Consul KV keys and values used in place of Consul
+    when rendering with -offline. Nested maps are joined using "/" to build
+    the full key.
+
+  -fail-on=<severity>
+    The minimum severity of problems which cause the command to fail. Valid
+    values are error, warning, info and off. The default is error.
+
+  -format=<format>
+    The output format. Valid values are human, json and github, which outputs
+    GitHub Actions workflow commands so that problems are annotated on the
+    template. The default is human.
+
+  -job-format=<format>
+    The format of the rendered job specification. Valid values are hcl and
+    json, where json is the Nomad API JSON format with or without the Job
+    wrapper. If not set, the format is detected from the rendered template.
+
+  -list-merge-strategy=<strategy>
+    How lists are merged when the same variable is declared in multiple
+    variable files. Valid values are replace and append. The default is
+    replace.
+
+  -log-level=<level>
+    Specify the verbosity level of Levant's logs. Valid values include DEBUG,
+    INFO, and WARN, in decreasing order of verbosity. The default is INFO.
+
+  -log-format=<format>
+    Specify the format of Levant's logs. Valid values are HUMAN or JSON. The
+    default is HUMAN.
+
+  -offline
+    Render the template without contacting Consul, Nomad, Vault or HTTP
+    servers. Consul KV lookups use the -consul-fixture file if passed, while
+    all other external lookups fail. Environment variables and local files
+    are read as normal.
+
+  -rule=<rule>=<severity>
+    Override the severity of a rule, where severity is one of error, warning,
+    info or off to disable the rule. Can be repeated.
+
+  -sensitive-pattern=<regex>
+    A regular expression used to identify sensitive variable names, in
+    addition to the default. Can be repeated.
+
+  -strict-vars
+    Fail rendering when the template references a variable which has not been
+    set, rather than rendering an empty value.
+
+  -template-path=<path>
+    A directory containing partial templates, or a glob pattern matching
+    them, which are loaded alongside the job template. Can be repeated.
+
+  -var-file=<file>
+    The variables file to render the template with. You can repeat this flag
+    multiple times to supply multiple var-files.
+    [default: levant.(json|yaml|yml|tf)]
+
+  -var-schema=<file>
+    Path to a YAML, JSON or Terraform file declaring the variables the
+    template expects.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// validateRulesHelp lists the built-in rules along with their default
+// severity.
+func validateRulesHelp() string {
+	var b strings.Builder
+	for _, r := range lint.Rules {
+		fmt.Fprintf(&b, "  %s (%s)\n    %s\n\n", r.Name, r.Severity, r.Description)
+	}
+	return b.String()
+}
+
+// Synopsis is provides a brief summary of the validate command.
+func (c *ValidateCommand) Synopsis() string {
+	return "Validate and lint a job template without a Nomad cluster"
+}
+
+// Run triggers a run of the Levant validate functions.
+func (c *ValidateCommand) Run(args []string) int {
+
+	var err error
+	var level, format, outFormat, failOn string
+	var rules []string
+
+	clientConfig := &structs.ClientConfig{}
+	config := &structs.TemplateConfig{}
+
+	flags := c.Meta.FlagSet("validate", FlagSetVars)
+	flags.Usage = func() { c.UI.Output(c.Help()) }
+
+	flags.StringVar(&clientConfig.Addr, "address", "", "")
+	flags.StringVar(&clientConfig.ConsulAddr, "consul-address", "", "")
+	flags.StringVar(&config.ConsulFixtureFile, "consul-fixture", "", "")
+	flags.StringVar(&failOn, "fail-on", string(lint.SeverityError), "")
+	flags.StringVar(&outFormat, "format", validateFormatHuman, "")
+	flags.StringVar(&config.JobFormat, "job-format", "", "")
+	flags.StringVar(&config.ListMergeStrategy, "list-merge-strategy", "replace", "")
+	flags.StringVar(&level, "log-level", "INFO", "")
+	flags.StringVar(&format, "log-format", "HUMAN", "")
+	flags.BoolVar(&config.Offline, "offline", false, "")
+	flags.Var((*helper.FlagStringSlice)(&rules), "rule", "")
+	flags.Var((*helper.FlagStringSlice)(&config.SensitivePatterns), "sensitive-pattern", "")
+	flags.BoolVar(&config.StrictVariables, "strict-vars", false, "")
+	flags.Var((*helper.FlagStringSlice)(&config.TemplatePaths), "template-path", "")
+	flags.Var((*helper.FlagStringSlice)(&config.VariableFiles), "var-file", "")
+	flags.StringVar(&config.VariableSchemaFile, "var-schema", "", "")
+
+	if err = flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+
+	if err = logging.SetupLogger(level, format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	switch len(args) {
+	case 0:
+		if config.TemplateFile = helper.GetDefaultTmplFile(); config.TemplateFile == "" {
+			c.UI.Error(c.Help())
+			c.UI.Error("\nERROR: Template arg missing and no default template found")
+			return 1
+		}
+	case 1:
+		config.TemplateFile = args[0]
+	default:
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	if outFormat != validateFormatHuman && outFormat != validateFormatJSON && outFormat != validateFormatGitHub {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: output format %q not supported", outFormat))
+		return 1
+	}
+
+	failSeverity, err := lint.ParseSeverity(failOn)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+
+	lintConfig := &lint.Config{TemplateFile: config.TemplateFile}
+	if lintConfig.Severities, err = parseRuleSeverities(rules); err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+
+	closeChart, err := loadChart(config)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+	defer closeChart()
+
+	tpl, job, err := template.RenderWithConfig(config, clientConfig, &c.Meta.flagVars)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+	lintConfig.Rendered = tpl.Bytes()
+
+	if lintConfig.Template, err = os.ReadFile(config.TemplateFile); err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+
+	findings, err := lint.Lint(job, lintConfig)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+
+	out, err := formatFindings(findings, outFormat)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+	if out != "" {
+		c.UI.Output(out)
+	}
+
+	if failSeverity == lint.SeverityOff {
+		return 0
+	}
+	for _, f := range findings {
+		if f.Severity.AtLeast(failSeverity) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseRuleSeverities parses the rule severity overrides, which are in the
+// format of rule=severity.
+func parseRuleSeverities(rules []string) (map[string]lint.Severity, error) {
+
+	severities := make(map[string]lint.Severity, len(rules))
+
+	for _, r := range rules {
+		name, sev, ok := strings.Cut(r, "=")
+		if !ok {
+			return nil, fmt.Errorf("rule %q must be in the format of rule=severity", r)
+		}
+
+		severity, err := lint.ParseSeverity(sev)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %v", name, err)
+		}
+		severities[name] = severity
+	}
+	return severities, nil
+}
+
+// githubDataEscaper and githubPropertyEscaper escape the message and
+// properties of GitHub Actions workflow commands, so that values cannot end
+// the command early or inject further commands.
+var (
+	githubDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// formatFindings formats the lint findings in the requested output format.
+func formatFindings(findings []*lint.Finding, outFormat string) (string, error) {
+
+	var b strings.Builder
+
+	switch outFormat {
+	case validateFormatJSON:
+		if findings == nil {
+			findings = []*lint.Finding{}
+		}
+		out, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+
+	case validateFormatGitHub:
+		for _, f := range findings {
+			command := "notice"
+			switch f.Severity {
+			case lint.SeverityError:
+				command = "error"
+			case lint.SeverityWarning:
+				command = "warning"
+			}
+
+			fmt.Fprintf(&b, "::%s file=%s", command, githubPropertyEscaper.Replace(f.File))
+			if f.Line > 0 {
+				fmt.Fprintf(&b, ",line=%v", f.Line)
+			}
+			fmt.Fprintf(&b, ",title=%s::%s\n", githubPropertyEscaper.Replace(f.Rule), githubDataEscaper.Replace(f.Message))
+		}
+
+	default:
+		counts := make(map[lint.Severity]int)
+		for _, f := range findings {
+			location := f.File
+			if f.Line > 0 {
+				location = fmt.Sprintf("%s:%v", f.File, f.Line)
+			}
+			fmt.Fprintf(&b, "%s: %s: %s (%s)\n", location, f.Severity, f.Message, f.Rule)
+			counts[f.Severity]++
+		}
+
+		if len(findings) == 0 {
+			b.WriteString("No problems found")
+		} else {
+			fmt.Fprintf(&b, "\nFound %v problems (%v errors, %v warnings, %v info)", len(findings),
+				counts[lint.SeverityError], counts[lint.SeverityWarning], counts[lint.SeverityInfo])
+		}
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
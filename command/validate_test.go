@@ -0,0 +1,33 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"testing"
+
+	"github.com/hashicorp/levant/lint"
+)
+
+func TestValidate_formatFindingsGitHub(t *testing.T) {
+
+	findings := []*lint.Finding{
+		{
+			Rule:     "image-tag",
+			Severity: lint.SeverityWarning,
+			Message:  "100% of tasks\n::error::injected",
+			File:     "jobs/web,api:v1.nomad",
+			Line:     12,
+		},
+	}
+
+	out, err := formatFindings(findings, validateFormatGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "::warning file=jobs/web%2Capi%3Av1.nomad,line=12,title=image-tag::100%25 of tasks%0A::error::injected"
+	if out != expected {
+		t.Fatalf("got: %s\nexpected: %s", out, expected)
+	}
+}
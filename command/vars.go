@@ -0,0 +1,244 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hashicorp/levant/helper"
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/hashicorp/levant/logging"
+	"github.com/hashicorp/levant/template"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	varsFormatJSON  = "json"
+	varsFormatTable = "table"
+	varsFormatYAML  = "yaml"
+)
+
+// VarsCommand is the command implementation that shows the resolved variables
+// used to render a template along with the source of each value.
+type VarsCommand struct {
+	Meta
+}
+
+// Help provides the help information for the vars command.
+func (c *VarsCommand) Help() string {
+	helpText := `
+Usage: levant vars [options] [TEMPLATE]
+
+  Show the final set of variables used to render a Nomad job template after
+  all variable files and command line variables have been merged. Each value
+  is shown along with where it was set, which can be a command line flag, a
+  variable file and line number, the default variable file, a remote source
+  or a schema default. Variables referenced by the template but not set are
+  also shown.
+
+Arguments:
+
+  TEMPLATE nomad job template
+    If no argument is given we look for a single *.nomad file
+    A chart directory or packaged chart archive can also be given
+
+General Options:
+
+  -address=<http_address>
+    The Nomad HTTP API address including port which Levant will use to make
+    calls when loading variables from Nomad Variables.
+
+  -consul-address=<addr>
+    The Consul host and port to use when loading variables from Consul KV.
+
+  -format=<format>
+    The output format. Valid values are table, json and yaml. The default is
+    table.
+
+  -list-merge-strategy=<strategy>
+    How lists are merged when the same variable is declared in multiple
+    variable files. Valid values are replace, where the later list replaces
+    the earlier, and append. Maps are always merged recursively. The default
+    is replace.
+
+  -log-level=<level>
+    Specify the verbosity level of Levant's logs. Valid values include DEBUG,
+    INFO, and WARN, in decreasing order of verbosity. The default is INFO.
+
+  -log-format=<format>
+    Specify the format of Levant's logs. Valid values are HUMAN or JSON. The
+    default is HUMAN.
+
+  -sensitive-pattern=<regex>
+    A regular expression used to identify sensitive variable names, in
+    addition to the default which matches names containing password, secret,
+    token, api_key, private_key or credential. The values of sensitive
+    variables are masked in all log output. Can be repeated.
+
+  -var-file=<file>
+    The variables file to render the template with. You can repeat this flag multiple
+    times to supply multiple var-files. Variables can also be loaded from a Nomad
+    Variable using nomadvar://<path>, a Consul KV prefix using
+    consul://kv/<prefix> or over HTTP(S) with an optional #sha256=<checksum>
+    pin. [default: levant.(json|yaml|yml|tf)]
+
+  -var-schema=<file>
+    Path to a YAML, JSON or Terraform file declaring the variables the
+    template expects. Values of variables declared as sensitive, or whose
+    names match a sensitive pattern, are redacted.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis is provides a brief summary of the vars command.
+func (c *VarsCommand) Synopsis() string {
+	return "Show the resolved variables of a template"
+}
+
+// Run triggers a run of the Levant vars functions.
+func (c *VarsCommand) Run(args []string) int {
+
+	var err error
+	var level, format, outFormat string
+
+	clientConfig := &structs.ClientConfig{}
+	config := &structs.TemplateConfig{}
+
+	flags := c.Meta.FlagSet("vars", FlagSetVars)
+	flags.Usage = func() { c.UI.Output(c.Help()) }
+
+	flags.StringVar(&clientConfig.Addr, "address", "", "")
+	flags.StringVar(&clientConfig.ConsulAddr, "consul-address", "", "")
+	flags.StringVar(&outFormat, "format", varsFormatTable, "")
+	flags.StringVar(&config.ListMergeStrategy, "list-merge-strategy", "replace", "")
+	flags.StringVar(&level, "log-level", "INFO", "")
+	flags.StringVar(&format, "log-format", "HUMAN", "")
+	flags.Var((*helper.FlagStringSlice)(&config.SensitivePatterns), "sensitive-pattern", "")
+	flags.Var((*helper.FlagStringSlice)(&config.VariableFiles), "var-file", "")
+	flags.StringVar(&config.VariableSchemaFile, "var-schema", "", "")
+
+	if err = flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+
+	if err = logging.SetupLogger(level, format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	switch len(args) {
+	case 0:
+		config.TemplateFile = helper.GetDefaultTmplFile()
+	case 1:
+		config.TemplateFile = args[0]
+	default:
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	if outFormat != varsFormatTable && outFormat != varsFormatJSON && outFormat != varsFormatYAML {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: output format %q not supported", outFormat))
+		return 1
+	}
+
+	closeChart, err := loadChart(config)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+	defer closeChart()
+
+	vars, err := template.ResolveVariables(config, clientConfig, &c.Meta.flagVars)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+
+	out, err := formatVars(vars, outFormat)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("[ERROR] levant/command: %v", err))
+		return 1
+	}
+
+	c.UI.Output(out)
+	return 0
+}
+
+// varsOutputLeaf is a single variable value within the JSON and YAML output.
+type varsOutputLeaf struct {
+	Value  interface{}              `json:"value" yaml:"value"`
+	Source *template.VariableSource `json:"source" yaml:"source"`
+}
+
+// formatVars formats the resolved variables in the requested output format.
+// The table format lists each variable by its path, while the JSON and YAML
+// formats output the variable tree with each value annotated with its source.
+func formatVars(vars []*template.ResolvedVariable, outFormat string) (string, error) {
+
+	if outFormat == varsFormatTable {
+		var b strings.Builder
+		writeVarsTable(&b, vars)
+		return strings.TrimSpace(b.String()), nil
+	}
+
+	tree := make(map[string]interface{})
+	for _, v := range vars {
+		leaf := &varsOutputLeaf{Value: v.Value, Source: v.Source}
+		if v.Sensitive {
+			leaf.Value = helper.RedactedValue
+		}
+		if err := helper.SetNestedVariable(tree, strings.Split(v.Path, "."), leaf); err != nil {
+			return "", err
+		}
+	}
+
+	var out []byte
+	var err error
+
+	if outFormat == varsFormatJSON {
+		out, err = json.MarshalIndent(tree, "", "  ")
+	} else {
+		out, err = yaml.Marshal(tree)
+	}
+	return strings.TrimSpace(string(out)), err
+}
+
+func writeVarsTable(w io.Writer, vars []*template.ResolvedVariable) {
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Variable\tValue\tSource")
+
+	for _, v := range vars {
+		value := formatVarValue(v.Value)
+		if v.Sensitive {
+			value = helper.RedactedValue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Path, value, v.Source)
+	}
+	tw.Flush()
+}
+
+// formatVarValue formats a variable value for table output, using JSON for
+// any value which is not a simple string.
+func formatVarValue(value interface{}) string {
+
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		return v
+	}
+
+	out, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(out)
+}
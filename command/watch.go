@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"strings"
+
+	"github.com/hashicorp/levant/levant"
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/hashicorp/levant/logging"
+)
+
+// WatchCommand is the command implementation that allows users to attach to
+// an in-progress Nomad deployment.
+type WatchCommand struct {
+	Meta
+}
+
+// Help provides the help information for the watch command.
+func (c *WatchCommand) Help() string {
+	helpText := `
+Usage: levant watch [options] <job|deployment-id>
+
+  Watch an existing Nomad deployment until it completes. When passed a job ID
+  the latest deployment of the job is watched, otherwise the argument is used
+  as a deployment ID or ID prefix. The deployment is tracked in the same way
+  as by the deploy command, including canary auto-promotion, inspection of
+  failed allocations and tracking of any auto-revert, and the command exits
+  with the same status codes.
+
+Arguments:
+
+  JOB|DEPLOYMENT-ID  The ID of a job or deployment to watch.
+
+General Options:
+
+  -address=<http_address>
+    The Nomad HTTP API address including port which Levant will use to make
+    calls.
+
+  -allow-stale
+    Allow stale consistency mode for requests into nomad.
+
+  -canary-auto-promote=<seconds>
+    The time in seconds, after which Levant will auto-promote the canaries
+    within the deployment if they are all healthy. The period starts when
+    Levant begins watching the deployment. Ignored if the deployment has no
+    canaries awaiting promotion.
+
+  -log-level=<level>
+    Specify the verbosity level of Levant's logs. Valid values include DEBUG,
+    INFO, and WARN, in decreasing order of verbosity. The default is INFO.
+
+  -log-format=<format>
+    Specify the format of Levant's logs. Valid values are HUMAN or JSON. The
+    default is HUMAN.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Synopsis is provides a brief summary of the watch command.
+func (c *WatchCommand) Synopsis() string {
+	return "Watch an in-progress Nomad deployment until it completes"
+}
+
+// Run triggers a run of the Levant watch functions.
+func (c *WatchCommand) Run(args []string) int {
+
+	var level, format string
+
+	config := &levant.DeployConfig{
+		Client: &structs.ClientConfig{},
+		Deploy: &structs.DeployConfig{},
+	}
+
+	flags := c.Meta.FlagSet("watch", FlagSetNone)
+	flags.Usage = func() { c.UI.Output(c.Help()) }
+
+	flags.StringVar(&config.Client.Addr, "address", "", "")
+	flags.BoolVar(&config.Client.AllowStale, "allow-stale", false, "")
+	flags.IntVar(&config.Deploy.Canary, "canary-auto-promote", 0, "")
+	flags.StringVar(&level, "log-level", "INFO", "")
+	flags.StringVar(&format, "log-format", "HUMAN", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	if err := logging.SetupLogger(level, format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if success := levant.TriggerWatch(config, args[0], nil); !success {
+		return 1
+	}
+
+	return 0
+}
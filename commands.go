@@ -40,11 +40,26 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"fail": func() (cli.Command, error) {
+			return &command.FailCommand{
+				Meta: meta,
+			}, nil
+		},
+		"package": func() (cli.Command, error) {
+			return &command.PackageCommand{
+				Meta: meta,
+			}, nil
+		},
 		"plan": func() (cli.Command, error) {
 			return &command.PlanCommand{
 				Meta: meta,
 			}, nil
 		},
+		"promote": func() (cli.Command, error) {
+			return &command.PromoteCommand{
+				Meta: meta,
+			}, nil
+		},
 		"render": func() (cli.Command, error) {
 			return &command.RenderCommand{
 				Meta: meta,
@@ -60,11 +75,41 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"status": func() (cli.Command, error) {
+			return &command.StatusCommand{
+				Meta: meta,
+			}, nil
+		},
+		"stop": func() (cli.Command, error) {
+			return &command.StopCommand{
+				Meta: meta,
+			}, nil
+		},
+		"test": func() (cli.Command, error) {
+			return &command.TestCommand{
+				Meta: meta,
+			}, nil
+		},
+		"validate": func() (cli.Command, error) {
+			return &command.ValidateCommand{
+				Meta: meta,
+			}, nil
+		},
+		"vars": func() (cli.Command, error) {
+			return &command.VarsCommand{
+				Meta: meta,
+			}, nil
+		},
 		"version": func() (cli.Command, error) {
 			return &command.VersionCommand{
 				Version: fmt.Sprintf("Levant %s", version.GetHumanVersion()),
 				UI:      meta.UI,
 			}, nil
 		},
+		"watch": func() (cli.Command, error) {
+			return &command.WatchCommand{
+				Meta: meta,
+			}, nil
+		},
 	}
 }
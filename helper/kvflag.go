@@ -4,12 +4,31 @@
 package helper
 
 import (
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 )
 
+// Supported variable types which can be used to type a command line variable
+// in the format of '-var key:type=value'.
+const (
+	VarTypeBool   = "bool"
+	VarTypeFloat  = "float"
+	VarTypeInt    = "int"
+	VarTypeJSON   = "json"
+	VarTypeString = "string"
+)
+
+// varTypes lists the supported variable types.
+var varTypes = []string{VarTypeBool, VarTypeFloat, VarTypeInt, VarTypeJSON, VarTypeString}
+
 // Flag is a flag.Value implementation for parsing user variables
-// from the command-line in the format of '-var key=value'.
+// from the command-line in the format of '-var key=value'. The key may be
+// suffixed with a type, such as '-var count:int=3', to store the value as
+// that type rather than a string. Keys with a suffix which is not a supported
+// type, such as '-var svc:port=80', are used as is.
 type Flag map[string]interface{}
 
 func (v *Flag) String() string {
@@ -23,34 +42,77 @@ func (v *Flag) Set(raw string) error {
 	if len(split) != 2 {
 		return fmt.Errorf("no '=' value in arg: %s", raw)
 	}
-	keyRaw, value := split[0], split[1]
+	keyRaw, valueRaw := split[0], split[1]
+
+	varType := VarTypeString
+	if i := strings.LastIndex(keyRaw, ":"); i != -1 && slices.Contains(varTypes, keyRaw[i+1:]) {
+		keyRaw, varType = keyRaw[:i], keyRaw[i+1:]
+	}
+
+	value, err := ParseTypedVariable(varType, valueRaw)
+	if err != nil {
+		return fmt.Errorf("unable to parse variable %s: %v", keyRaw, err)
+	}
 
+	return v.set(keyRaw, value)
+}
+
+// set adds the value to the flag map, splitting the variable key based on the
+// nested delimiter to get a list of nested keys.
+func (v *Flag) set(key string, value interface{}) error {
 	if *v == nil {
 		*v = make(map[string]interface{})
 	}
+	return SetNestedVariable(*v, strings.Split(key, "."), value)
+}
 
-	// Split the variable key based on the nested delimiter to get a list of
-	// nested keys.
-	keys := strings.Split(keyRaw, ".")
-
-	lastKeyIdx := len(keys) - 1
-	// Find the nested map where this value belongs
-	// create missing maps as we go
-	target := *v
-	for i := 0; i < lastKeyIdx; i++ {
-		raw, ok := target[keys[i]]
-		if !ok {
-			raw = make(map[string]interface{})
-			target[keys[i]] = raw
-		}
-		var newTarget Flag
-		if newTarget, ok = raw.(map[string]interface{}); !ok {
-			return fmt.Errorf("simple value already exists at key %q", strings.Join(keys[:i+1], "."))
+// FlagJSON is a flag.Value implementation for parsing user variables with JSON
+// values from the command-line in the format of '-var-json key={"a":1}'. It
+// shares the underlying map with Flag so that both can be used together.
+type FlagJSON Flag
+
+func (v *FlagJSON) String() string {
+	return ""
+}
+
+// Set takes a JSON flag variable argument, decodes the value and adds it to
+// the map.
+func (v *FlagJSON) Set(raw string) error {
+	split := strings.SplitN(raw, "=", 2)
+	if len(split) != 2 {
+		return fmt.Errorf("no '=' value in arg: %s", raw)
+	}
+
+	value, err := ParseTypedVariable(VarTypeJSON, split[1])
+	if err != nil {
+		return fmt.Errorf("unable to parse variable %s: %v", split[0], err)
+	}
+
+	return (*Flag)(v).set(split[0], value)
+}
+
+// ParseTypedVariable converts the raw string value of a variable into the
+// passed variable type.
+func ParseTypedVariable(varType, raw string) (interface{}, error) {
+	switch varType {
+	case VarTypeString:
+		return raw, nil
+	case VarTypeBool:
+		return strconv.ParseBool(raw)
+	case VarTypeFloat:
+		return strconv.ParseFloat(raw, 64)
+	case VarTypeInt:
+		return strconv.Atoi(raw)
+	case VarTypeJSON:
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("invalid JSON value: %v", err)
 		}
-		target = newTarget
+		return value, nil
+	default:
+		return nil, fmt.Errorf("variable type %q not supported, must be one of %s",
+			varType, strings.Join(varTypes, ", "))
 	}
-	target[keys[lastKeyIdx]] = value
-	return nil
 }
 
 // FlagStringSlice is a flag.Value implementation for parsing targets from the
@@ -72,6 +72,42 @@ func TestHelper_Set(t *testing.T) {
 			nil,
 			true,
 		},
+		{
+			"typed values",
+			[]string{"count:int=3", "ratio:float=0.5", "enabled:bool=true", "name:string=web"},
+			map[string]interface{}{"count": 3, "ratio": 0.5, "enabled": true, "name": "web"},
+			false,
+		},
+		{
+			"typed nested value",
+			[]string{"resources.cpu:int=500"},
+			map[string]interface{}{"resources": map[string]interface{}{"cpu": 500}},
+			false,
+		},
+		{
+			"typed json value",
+			[]string{`tags:json=["a","b"]`},
+			map[string]interface{}{"tags": []interface{}{"a", "b"}},
+			false,
+		},
+		{
+			"invalid typed value",
+			[]string{"count:int=three"},
+			nil,
+			true,
+		},
+		{
+			"unsupported type suffix",
+			[]string{"count:integer=3", "svc:port=80"},
+			map[string]interface{}{"count:integer": "3", "svc:port": "80"},
+			false,
+		},
+		{
+			"typed key containing colon",
+			[]string{"svc:port:int=80"},
+			map[string]interface{}{"svc:port": 80},
+			false,
+		},
 	}
 
 	for _, tc := range cases {
@@ -93,3 +129,20 @@ func TestHelper_Set(t *testing.T) {
 		})
 	}
 }
+
+func TestHelper_SetJSON(t *testing.T) {
+	vars := map[string]interface{}{}
+
+	require.NoError(t, (*Flag)(&vars).Set("name=web"))
+	require.NoError(t, (*FlagJSON)(&vars).Set(`resources={"cpu":500,"tags":["a"]}`))
+	require.Error(t, (*FlagJSON)(&vars).Set(`invalid={"cpu":`))
+
+	expected := map[string]interface{}{
+		"name": "web",
+		"resources": map[string]interface{}{
+			"cpu":  float64(500),
+			"tags": []interface{}{"a"},
+		},
+	}
+	require.Equal(t, expected, vars)
+}
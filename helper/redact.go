@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces sensitive values within log output and reports.
+const RedactedValue = "<sensitive>"
+
+// MinSensitiveLength is the minimum length of a value which is masked. Shorter
+// values, such as "1" or "true", would mask unrelated output.
+const MinSensitiveLength = 4
+
+// DefaultSensitivePattern matches variable names which are treated as
+// sensitive without needing to be declared as such.
+const DefaultSensitivePattern = `(?i)(passw(or)?d|secret|token|api_?key|private_?key|credential)`
+
+// redactor holds the sensitive values and variable name patterns registered
+// during the run. Values are masked wherever they appear in log output.
+type redactor struct {
+	sync.RWMutex
+	values   map[string]struct{}
+	patterns []*regexp.Regexp
+	replacer *strings.Replacer
+}
+
+var sensitive = newRedactor()
+
+// newRedactor returns a redactor holding no sensitive values and only the
+// default sensitive name pattern.
+func newRedactor() *redactor {
+	return &redactor{
+		values:   make(map[string]struct{}),
+		patterns: []*regexp.Regexp{regexp.MustCompile(DefaultSensitivePattern)},
+	}
+}
+
+// ResetSensitive removes all registered sensitive values and additional name
+// patterns, primarily so tests do not affect each other.
+func ResetSensitive() {
+	r := newRedactor()
+
+	sensitive.Lock()
+	defer sensitive.Unlock()
+
+	sensitive.values, sensitive.patterns, sensitive.replacer = r.values, r.patterns, nil
+}
+
+// MarkSensitive registers the passed value as sensitive so that it is masked
+// within all subsequent log output. Values shorter than MinSensitiveLength
+// are ignored.
+func MarkSensitive(value string) {
+	if len(value) < MinSensitiveLength {
+		return
+	}
+
+	sensitive.Lock()
+	defer sensitive.Unlock()
+
+	if _, ok := sensitive.values[value]; ok {
+		return
+	}
+	sensitive.values[value] = struct{}{}
+	sensitive.replacer = nil
+}
+
+// AddSensitivePattern registers an additional regular expression which is
+// used to identify sensitive variable names.
+func AddSensitivePattern(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid sensitive pattern %q: %v", pattern, err)
+	}
+
+	sensitive.Lock()
+	defer sensitive.Unlock()
+
+	sensitive.patterns = append(sensitive.patterns, re)
+	return nil
+}
+
+// IsSensitiveName returns whether the passed variable or field name matches
+// any of the registered sensitive name patterns.
+func IsSensitiveName(name string) bool {
+	sensitive.RLock()
+	defer sensitive.RUnlock()
+
+	for _, re := range sensitive.patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact masks every registered sensitive value within the passed string.
+func Redact(s string) string {
+	sensitive.Lock()
+	defer sensitive.Unlock()
+
+	if len(sensitive.values) == 0 {
+		return s
+	}
+
+	if sensitive.replacer == nil {
+		sensitive.replacer = newRedactReplacer(sensitive.values)
+	}
+	return sensitive.replacer.Replace(s)
+}
+
+// newRedactReplacer builds a replacer for the sensitive values. Both the raw
+// and JSON escaped form of each value are replaced so that values are masked
+// in JSON log output, with the longest values replaced first.
+func newRedactReplacer(values map[string]struct{}) *strings.Replacer {
+
+	forms := make(map[string]struct{})
+	for v := range values {
+		forms[v] = struct{}{}
+		if b, err := json.Marshal(v); err == nil {
+			forms[strings.Trim(string(b), `"`)] = struct{}{}
+		}
+	}
+
+	sorted := make([]string, 0, len(forms))
+	for v := range forms {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	oldnew := make([]string, 0, len(sorted)*2)
+	for _, v := range sorted {
+		oldnew = append(oldnew, v, RedactedValue)
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+// redactWriter is an io.Writer which masks sensitive values before writing to
+// the underlying writer.
+type redactWriter struct {
+	w io.Writer
+}
+
+// NewRedactWriter wraps the passed writer so that all registered sensitive
+// values are masked before being written.
+func NewRedactWriter(w io.Writer) io.Writer {
+	return &redactWriter{w: w}
+}
+
+func (r *redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,51 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package helper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelper_Redact(t *testing.T) {
+	t.Cleanup(ResetSensitive)
+
+	MarkSensitive("hunter2")
+	MarkSensitive(`pa"ss`)
+	MarkSensitive("")
+	MarkSensitive("60")
+
+	require.Equal(t, "password is "+RedactedValue, Redact("password is hunter2"))
+	require.Equal(t, `{"message":"`+RedactedValue+`"}`, Redact(`{"message":"pa\"ss"}`))
+	require.Equal(t, "nothing to see", Redact("nothing to see"))
+	require.Equal(t, "timeout 60s", Redact("timeout 60s"))
+
+	var b bytes.Buffer
+	w := NewRedactWriter(&b)
+
+	n, err := w.Write([]byte("token hunter2\n"))
+	require.NoError(t, err)
+	require.Equal(t, 14, n)
+	require.Equal(t, "token "+RedactedValue+"\n", b.String())
+}
+
+func TestHelper_IsSensitiveName(t *testing.T) {
+	t.Cleanup(ResetSensitive)
+
+	for _, name := range []string{"db_password", "DB_PASSWD", "vault_token", "client_secret", "apiKey", "private_key"} {
+		require.True(t, IsSensitiveName(name), name)
+	}
+	for _, name := range []string{"job_name", "count", "datacenters"} {
+		require.False(t, IsSensitiveName(name), name)
+	}
+
+	require.Error(t, AddSensitivePattern("("))
+	require.NoError(t, AddSensitivePattern("^license$"))
+	require.True(t, IsSensitiveName("license"))
+
+	ResetSensitive()
+	require.False(t, IsSensitiveName("license"))
+}
@@ -4,30 +4,140 @@
 package helper
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	// ListMergeReplace is the list merge strategy where a list from a later
+	// variable source replaces the list from an earlier source.
+	ListMergeReplace = "replace"
+
+	// ListMergeAppend is the list merge strategy where a list from a later
+	// variable source is appended to the list from an earlier source.
+	ListMergeAppend = "append"
+)
+
 // VariableMerge merges the passed file variables with the flag variabes to
 // provide a single set of variables. The flagVars will always prevale over file
-// variables.
+// variables, with nested flag variables only overriding the matching leaf of
+// any nested file variables.
 func VariableMerge(fileVars, flagVars *map[string]interface{}) map[string]interface{} {
 
-	out := make(map[string]interface{})
+	// Variable values are not logged as they may contain sensitive data; the
+	// levant vars command can be used to inspect the merged values.
+	for k := range *flagVars {
+		log.Info().Msgf("helper/variable: using command line variable with key %s", k)
+	}
+
+	for k := range *fileVars {
+		if _, ok := (*flagVars)[k]; ok {
+			log.Debug().Msgf("helper/variable: variable from file with key %s merged with CLI var", k)
+			continue
+		}
+		log.Info().Msgf("helper/variable: using variable with key %s from file", k)
+	}
 
-	for k, v := range *flagVars {
-		log.Info().Msgf("helper/variable: using command line variable with key %s and value %s", k, v)
+	return MergeVariables(*fileVars, *flagVars, ListMergeReplace)
+}
+
+// ValidateListMergeStrategy checks the passed list merge strategy is one that
+// is supported. An empty strategy is valid and defaults to replace.
+func ValidateListMergeStrategy(strategy string) error {
+	switch strategy {
+	case "", ListMergeReplace, ListMergeAppend:
+		return nil
+	default:
+		return fmt.Errorf("unsupported list merge strategy: %q (supported strategies: %s %s)",
+			strategy, ListMergeReplace, ListMergeAppend)
+	}
+}
+
+// MergeVariables performs a deep merge of the src variables into the dst
+// variables, returning the result without modifying either input. Nested maps
+// are merged recursively with src taking precedence for any conflicting leaf.
+// Lists are handled according to the passed list merge strategy.
+func MergeVariables(dst, src map[string]interface{}, listStrategy string) map[string]interface{} {
+
+	out := make(map[string]interface{}, len(dst))
+	for k, v := range dst {
 		out[k] = v
 	}
 
-	for k, v := range *fileVars {
-		if _, ok := out[k]; ok {
-			log.Debug().Msgf("helper/variable: variable from file with key %s and value %s overridden by CLI var",
-				k, v)
+	for k, v := range src {
+		if existing, ok := out[k]; ok {
+			out[k] = mergeVariable(existing, v, listStrategy)
 			continue
 		}
-		log.Info().Msgf("helper/variable: using variable with key %s and value %v from file", k, v)
 		out[k] = v
 	}
 
 	return out
 }
+
+// mergeVariable merges a single src value into the dst value.
+func mergeVariable(dst, src interface{}, listStrategy string) interface{} {
+
+	if dstMap, ok := toVariableMap(dst); ok {
+		if srcMap, ok := toVariableMap(src); ok {
+			return MergeVariables(dstMap, srcMap, listStrategy)
+		}
+	}
+
+	if listStrategy == ListMergeAppend {
+		dstList, dstOK := dst.([]interface{})
+		srcList, srcOK := src.([]interface{})
+		if dstOK && srcOK {
+			return append(append([]interface{}{}, dstList...), srcList...)
+		}
+	}
+
+	return src
+}
+
+// toVariableMap converts the passed value into a variables map if possible.
+// YAML decodes nested maps with interface keys, so these are converted to use
+// string keys.
+func toVariableMap(v interface{}) (map[string]interface{}, bool) {
+
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			out[fmt.Sprintf("%v", k)] = v
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
+
+// SetNestedVariable sets the value within the variables map at the location
+// described by the list of nested keys, creating any missing maps as it goes.
+func SetNestedVariable(variables map[string]interface{}, keys []string, value interface{}) error {
+
+	lastKeyIdx := len(keys) - 1
+
+	// Find the nested map where this value belongs
+	// create missing maps as we go
+	target := variables
+	for i := 0; i < lastKeyIdx; i++ {
+		raw, ok := target[keys[i]]
+		if !ok {
+			raw = make(map[string]interface{})
+			target[keys[i]] = raw
+		}
+		var newTarget map[string]interface{}
+		if newTarget, ok = raw.(map[string]interface{}); !ok {
+			return fmt.Errorf("simple value already exists at key %q", strings.Join(keys[:i+1], "."))
+		}
+		target = newTarget
+	}
+	target[keys[lastKeyIdx]] = value
+
+	return nil
+}
@@ -29,3 +29,74 @@ func TestHelper_VariableMerge(t *testing.T) {
 		t.Fatalf("expected \n%#v\n\n, got \n\n%#v\n\n", expected, res)
 	}
 }
+
+func TestHelper_VariableMergeNested(t *testing.T) {
+
+	flagVars := map[string]interface{}{
+		"resources": map[string]interface{}{"cpu": "1000"},
+	}
+	fileVars := map[string]interface{}{
+		"resources": map[interface{}]interface{}{"cpu": 250, "memory": 512},
+	}
+
+	expected := map[string]interface{}{
+		"resources": map[string]interface{}{"cpu": "1000", "memory": 512},
+	}
+
+	res := VariableMerge(&fileVars, &flagVars)
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("expected \n%#v\n\n, got \n\n%#v\n\n", expected, res)
+	}
+}
+
+func TestHelper_MergeVariables(t *testing.T) {
+
+	base := map[string]interface{}{
+		"job_name":    "levantExample",
+		"datacenters": []interface{}{"dc1"},
+		"resources": map[string]interface{}{
+			"cpu":    250,
+			"memory": 512,
+		},
+	}
+	override := map[string]interface{}{
+		"datacenters": []interface{}{"dc2"},
+		"resources": map[string]interface{}{
+			"cpu": 500,
+		},
+	}
+
+	cases := []struct {
+		Strategy string
+		Expected map[string]interface{}
+	}{
+		{
+			ListMergeReplace,
+			map[string]interface{}{
+				"job_name":    "levantExample",
+				"datacenters": []interface{}{"dc2"},
+				"resources":   map[string]interface{}{"cpu": 500, "memory": 512},
+			},
+		},
+		{
+			ListMergeAppend,
+			map[string]interface{}{
+				"job_name":    "levantExample",
+				"datacenters": []interface{}{"dc1", "dc2"},
+				"resources":   map[string]interface{}{"cpu": 500, "memory": 512},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		res := MergeVariables(base, override, tc.Strategy)
+		if !reflect.DeepEqual(res, tc.Expected) {
+			t.Fatalf("expected \n%#v\n\n, got \n\n%#v\n\n", tc.Expected, res)
+		}
+	}
+
+	// Ensure the inputs were not modified by the merge.
+	if base["resources"].(map[string]interface{})["cpu"] != 250 {
+		t.Fatalf("expected base variables to be unmodified")
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jobtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// assertOperators are the comparison operators supported by assertions,
+// ordered so that two character operators are matched first.
+var assertOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// assertion is a parsed assertion in the form of <path> <operator> <value>.
+type assertion struct {
+	path     string
+	length   bool
+	operator string
+	value    interface{}
+}
+
+// parseAssertion parses an assertion such as TaskGroups[api].Count == 3. The
+// path can be wrapped in len() to compare its length. Values are JSON, with
+// unquoted values which are not valid JSON treated as strings.
+func parseAssertion(s string) (*assertion, error) {
+
+	i, op := findOperator(s)
+	if i < 0 {
+		return nil, fmt.Errorf("assertion %q must compare a path to a value using one of %s",
+			s, strings.Join(assertOperators, ", "))
+	}
+
+	a := &assertion{
+		path:     strings.TrimSpace(s[:i]),
+		operator: op,
+	}
+
+	if strings.HasPrefix(a.path, "len(") && strings.HasSuffix(a.path, ")") {
+		a.length = true
+		a.path = strings.TrimSpace(a.path[4 : len(a.path)-1])
+	}
+	if a.path == "" {
+		return nil, fmt.Errorf("assertion %q must include a path", s)
+	}
+
+	raw := strings.TrimSpace(s[i+len(op):])
+	if err := json.Unmarshal([]byte(raw), &a.value); err != nil {
+		a.value = raw
+	}
+	return a, nil
+}
+
+// findOperator returns the index and operator of the first comparison
+// operator which is not within a quoted string or path selector.
+func findOperator(s string) (int, string) {
+
+	var quoted bool
+	var depth int
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case depth == 0:
+			for _, op := range assertOperators {
+				if strings.HasPrefix(s[i:], op) {
+					return i, op
+				}
+			}
+		}
+	}
+	return -1, ""
+}
+
+// check evaluates the assertion against the passed job, returning an error
+// describing the failure if the assertion does not hold.
+func (a *assertion) check(job interface{}) error {
+
+	v, err := resolvePath(reflect.ValueOf(job), a.path)
+	if err != nil {
+		return err
+	}
+
+	var actual interface{}
+	if a.length {
+		if !v.IsValid() {
+			actual = float64(0)
+		} else {
+			switch v.Kind() {
+			case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+				actual = float64(v.Len())
+			default:
+				return fmt.Errorf("%s does not have a length", a.path)
+			}
+		}
+	} else if actual, err = normalizeValue(v, a.value); err != nil {
+		return err
+	}
+
+	ok, err := compare(actual, a.operator, a.value)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		out, _ := json.Marshal(actual)
+		return fmt.Errorf("got %s", out)
+	}
+	return nil
+}
+
+// normalizeValue converts the resolved value into the same form as values
+// decoded from JSON, so it can be compared with the expected value. Durations
+// are compared as duration strings when the expected value is a string.
+func normalizeValue(v reflect.Value, expected interface{}) (interface{}, error) {
+
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if d, ok := v.Interface().(time.Duration); ok {
+		if s, ok := expected.(string); ok {
+			if want, err := time.ParseDuration(s); err == nil {
+				if d == want {
+					return s, nil
+				}
+				return d.String(), nil
+			}
+		}
+	}
+
+	out, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	var actual interface{}
+	if err := json.Unmarshal(out, &actual); err != nil {
+		return nil, err
+	}
+	return actual, nil
+}
+
+// compare compares the actual value with the expected value using the
+// operator. Ordering operators are only supported for numbers and strings.
+func compare(actual interface{}, op string, expected interface{}) (bool, error) {
+
+	switch op {
+	case "==":
+		return reflect.DeepEqual(actual, expected), nil
+	case "!=":
+		return !reflect.DeepEqual(actual, expected), nil
+	}
+
+	var cmp int
+
+	switch a := actual.(type) {
+	case float64:
+		e, ok := expected.(float64)
+		if !ok {
+			return false, fmt.Errorf("cannot compare number with %v using %s", expected, op)
+		}
+		switch {
+		case a < e:
+			cmp = -1
+		case a > e:
+			cmp = 1
+		}
+	case string:
+		e, ok := expected.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot compare string with %v using %s", expected, op)
+		}
+		cmp = strings.Compare(a, e)
+	default:
+		return false, fmt.Errorf("cannot compare %v using %s", actual, op)
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// resolvePath resolves the dotted path against the value. Each path segment
+// is a struct field or map key, and can be followed by selectors in square
+// brackets. Selectors index a slice by position, or select the element whose
+// Name, Label or ID matches the selector, and select the key of a map. An
+// invalid value is returned if the path resolves to a nil value.
+func resolvePath(v reflect.Value, path string) (reflect.Value, error) {
+
+	segments, err := splitPath(path)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	for _, seg := range segments {
+		v = indirect(v)
+		if !v.IsValid() {
+			return v, nil
+		}
+
+		if seg.field != "" {
+			if v, err = field(v, seg.field); err != nil {
+				return reflect.Value{}, fmt.Errorf("%s: %v", path, err)
+			}
+		}
+
+		for _, sel := range seg.selectors {
+			v = indirect(v)
+			if !v.IsValid() {
+				return v, nil
+			}
+			if v, err = selectElem(v, sel); err != nil {
+				return reflect.Value{}, fmt.Errorf("%s: %v", path, err)
+			}
+		}
+	}
+
+	return indirect(v), nil
+}
+
+// pathSegment is a single field of a path along with its selectors.
+type pathSegment struct {
+	field     string
+	selectors []string
+}
+
+func splitPath(path string) ([]*pathSegment, error) {
+
+	var segments []*pathSegment
+	seg := &pathSegment{}
+
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '.':
+			segments = append(segments, seg)
+			seg = &pathSegment{}
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("path %s has an unclosed selector", path)
+			}
+			seg.selectors = append(seg.selectors, strings.Trim(path[i+1:i+end], `"`))
+			i += end
+		default:
+			if len(seg.selectors) > 0 {
+				return nil, fmt.Errorf("path %s has an invalid selector", path)
+			}
+			seg.field += string(c)
+		}
+	}
+	segments = append(segments, seg)
+
+	for _, s := range segments {
+		if s.field == "" && len(s.selectors) == 0 {
+			return nil, fmt.Errorf("path %s has an empty segment", path)
+		}
+	}
+	return segments, nil
+}
+
+// indirect dereferences pointers and interfaces, returning an invalid value
+// for nil values.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// field returns the named struct field, matched case insensitively, or the
+// map key.
+func field(v reflect.Value, name string) (reflect.Value, error) {
+
+	switch v.Kind() {
+	case reflect.Struct:
+		f := v.FieldByNameFunc(func(n string) bool { return strings.EqualFold(n, name) })
+		if !f.IsValid() {
+			return f, fmt.Errorf("field %s not found", name)
+		}
+		return f, nil
+	case reflect.Map:
+		return mapKey(v, name)
+	default:
+		return reflect.Value{}, fmt.Errorf("cannot select field %s of %s", name, v.Type())
+	}
+}
+
+// selectElem returns the element of the slice or map identified by the
+// selector.
+func selectElem(v reflect.Value, sel string) (reflect.Value, error) {
+
+	switch v.Kind() {
+	case reflect.Map:
+		return mapKey(v, sel)
+
+	case reflect.Slice, reflect.Array:
+		if i, err := strconv.Atoi(sel); err == nil {
+			if i < 0 || i >= v.Len() {
+				return reflect.Value{}, fmt.Errorf("index %v out of range", i)
+			}
+			return v.Index(i), nil
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			elem := indirect(v.Index(i))
+			if elem.Kind() != reflect.Struct {
+				continue
+			}
+			for _, name := range []string{"Name", "Label", "ID"} {
+				f := indirect(elem.FieldByName(name))
+				if f.IsValid() && f.Kind() == reflect.String && f.String() == sel {
+					return v.Index(i), nil
+				}
+			}
+		}
+		return reflect.Value{}, fmt.Errorf("element %s not found", sel)
+
+	default:
+		return reflect.Value{}, fmt.Errorf("cannot select %s of %s", sel, v.Type())
+	}
+}
+
+func mapKey(v reflect.Value, key string) (reflect.Value, error) {
+	if v.Type().Key().Kind() != reflect.String {
+		return reflect.Value{}, fmt.Errorf("cannot select key %s of %s", key, v.Type())
+	}
+	return v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())), nil
+}
@@ -0,0 +1,312 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jobtest
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/terraform/configs/hcl2shim"
+	"github.com/zclconf/go-cty/cty"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// testFileSuffixes are the suffixes of the file names of template tests.
+var testFileSuffixes = []string{"_test.hcl", "_test.yaml", "_test.yml"}
+
+// File is a template test file holding the tests of a single job template.
+type File struct {
+	// Path is the path of the test file.
+	Path string
+
+	// Template is the path of the job template under test. By default this
+	// is the test file name with the test suffix replaced by .nomad.
+	Template string
+
+	// TemplatePaths, VariableFiles and VariableSchemaFile configure the
+	// rendering of the template for all tests within the file.
+	TemplatePaths      []string
+	VariableFiles      []string
+	VariableSchemaFile string
+
+	Tests []*Test
+}
+
+// Test is a single template test, which renders the template with the
+// test variables and fixtures and checks the assertions against the job.
+type Test struct {
+	Name string
+
+	// Vars are the variables passed to the template, which take precedence
+	// over the variable files in the same manner as command line variables.
+	Vars map[string]interface{}
+
+	// ConsulKV, Env and Files are used in place of Consul KV, environment
+	// variable and file lookups made by the template.
+	ConsulKV map[string]string
+	Env      map[string]string
+	Files    map[string]string
+
+	// Assert are the assertions checked against the rendered job, such as
+	// TaskGroups[api].Count == 3.
+	Assert []string
+
+	// ExpectError, if set, expects rendering the template to fail with an
+	// error containing the value.
+	ExpectError string
+}
+
+// hclFile and hclTest are the HCL form of a test file.
+type hclFile struct {
+	Template      string     `hcl:"template,optional"`
+	TemplatePaths []string   `hcl:"template_paths,optional"`
+	VarFiles      []string   `hcl:"var_files,optional"`
+	VarSchema     string     `hcl:"var_schema,optional"`
+	Tests         []*hclTest `hcl:"test,block"`
+}
+
+type hclTest struct {
+	Name        string            `hcl:"name,label"`
+	Vars        cty.Value         `hcl:"vars,optional"`
+	ConsulKV    map[string]string `hcl:"consul_kv,optional"`
+	Env         map[string]string `hcl:"env,optional"`
+	Files       map[string]string `hcl:"files,optional"`
+	Assert      []string          `hcl:"assert,optional"`
+	ExpectError string            `hcl:"expect_error,optional"`
+}
+
+// yamlFile and yamlTest are the YAML form of a test file.
+type yamlFile struct {
+	Template      string      `yaml:"template"`
+	TemplatePaths []string    `yaml:"template_paths"`
+	VarFiles      []string    `yaml:"var_files"`
+	VarSchema     string      `yaml:"var_schema"`
+	Tests         []*yamlTest `yaml:"tests"`
+}
+
+type yamlTest struct {
+	Name        string                 `yaml:"name"`
+	Vars        map[string]interface{} `yaml:"vars"`
+	ConsulKV    map[string]string      `yaml:"consul_kv"`
+	Env         map[string]string      `yaml:"env"`
+	Files       map[string]string      `yaml:"files"`
+	Assert      []string               `yaml:"assert"`
+	ExpectError string                 `yaml:"expect_error"`
+}
+
+// IsTestFile returns whether the file name is that of a template test file.
+func IsTestFile(name string) bool {
+	return testFileSuffix(name) != ""
+}
+
+func testFileSuffix(name string) string {
+	for _, suffix := range testFileSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+// Discover returns the template test files found within the passed paths.
+// Directories are searched recursively, skipping hidden directories, while
+// files are returned as passed.
+func Discover(paths []string) ([]string, error) {
+
+	var files []string
+
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+
+		err = filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != p && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if IsTestFile(d.Name()) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// ParseFile parses the template test file at the passed path. Paths within
+// the file are relative to the directory containing the file.
+func ParseFile(path string) (*File, error) {
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f *File
+	if filepath.Ext(path) == ".hcl" {
+		f, err = parseHCLFile(path, src)
+	} else {
+		f, err = parseYAMLFile(src)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse test file %s: %v", path, err)
+	}
+
+	f.Path = path
+	dir := filepath.Dir(path)
+
+	if f.Template == "" {
+		base := filepath.Base(path)
+		f.Template = strings.TrimSuffix(base, testFileSuffix(base)) + ".nomad"
+	}
+	f.Template = relativeTo(dir, f.Template)
+	f.VariableSchemaFile = relativeTo(dir, f.VariableSchemaFile)
+	for i := range f.TemplatePaths {
+		f.TemplatePaths[i] = relativeTo(dir, f.TemplatePaths[i])
+	}
+	for i := range f.VariableFiles {
+		f.VariableFiles[i] = relativeTo(dir, f.VariableFiles[i])
+	}
+
+	names := make(map[string]bool)
+	for _, t := range f.Tests {
+		if t.Name == "" {
+			return nil, fmt.Errorf("test file %s includes a test without a name", path)
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("test file %s includes multiple tests named %q", path, t.Name)
+		}
+		names[t.Name] = true
+	}
+
+	return f, nil
+}
+
+// relativeTo returns the path relative to the directory, unless it is
+// absolute or empty.
+func relativeTo(dir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+func parseHCLFile(path string, src []byte) (*File, error) {
+
+	file, diags := hclparse.NewParser().ParseHCL(src, path)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	raw := &hclFile{}
+	if diags := gohcl.DecodeBody(file.Body, nil, raw); diags.HasErrors() {
+		return nil, diags
+	}
+
+	f := &File{
+		Template:           raw.Template,
+		TemplatePaths:      raw.TemplatePaths,
+		VariableFiles:      raw.VarFiles,
+		VariableSchemaFile: raw.VarSchema,
+	}
+
+	for _, t := range raw.Tests {
+		test := &Test{
+			Name:        t.Name,
+			ConsulKV:    t.ConsulKV,
+			Env:         t.Env,
+			Files:       t.Files,
+			Assert:      t.Assert,
+			ExpectError: t.ExpectError,
+		}
+
+		if !t.Vars.IsNull() && t.Vars.IsKnown() {
+			if !t.Vars.Type().IsObjectType() && !t.Vars.Type().IsMapType() {
+				return nil, fmt.Errorf("test %q vars must be an object", t.Name)
+			}
+			test.Vars, _ = hcl2shim.ConfigValueFromHCL2(t.Vars).(map[string]interface{})
+		}
+
+		f.Tests = append(f.Tests, test)
+	}
+	return f, nil
+}
+
+func parseYAMLFile(src []byte) (*File, error) {
+
+	raw := &yamlFile{}
+	if err := yaml.UnmarshalStrict(src, raw); err != nil {
+		return nil, err
+	}
+
+	f := &File{
+		Template:           raw.Template,
+		TemplatePaths:      raw.TemplatePaths,
+		VariableFiles:      raw.VarFiles,
+		VariableSchemaFile: raw.VarSchema,
+	}
+
+	for _, t := range raw.Tests {
+		f.Tests = append(f.Tests, &Test{
+			Name:        t.Name,
+			Vars:        normalizeYAML(t.Vars).(map[string]interface{}),
+			ConsulKV:    t.ConsulKV,
+			Env:         t.Env,
+			Files:       t.Files,
+			Assert:      t.Assert,
+			ExpectError: t.ExpectError,
+		})
+	}
+	return f, nil
+}
+
+// normalizeYAML converts the nested maps decoded from YAML, which have
+// interface keys, into maps with string keys.
+func normalizeYAML(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			out[fmt.Sprint(k)] = normalizeYAML(v)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			out[k] = normalizeYAML(v)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i, v := range typed {
+			out[i] = normalizeYAML(v)
+		}
+		return out
+	default:
+		return v
+	}
+}
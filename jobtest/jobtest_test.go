@@ -0,0 +1,130 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jobtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestJobTest_Discover(t *testing.T) {
+
+	files, err := Discover([]string{"test-fixtures"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"test-fixtures/example_test.hcl", "test-fixtures/failing_test.yaml"}
+	if fmt.Sprint(files) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v but got %v", expected, files)
+	}
+}
+
+func TestJobTest_RunHCL(t *testing.T) {
+
+	f, err := ParseFile("test-fixtures/example_test.hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Template != "test-fixtures/example.nomad" {
+		t.Fatalf("expected default template but got %s", f.Template)
+	}
+
+	results := Run(f, nil)
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results but got %v", len(results))
+	}
+	for _, r := range results {
+		if !r.Passed() {
+			t.Fatalf("expected test %q to pass but got %v", r.Test, r.Failures)
+		}
+	}
+
+	if results = Run(f, regexp.MustCompile("^scaled$")); len(results) != 1 {
+		t.Fatalf("expected 1 filtered result but got %v", len(results))
+	}
+}
+
+func TestJobTest_RunYAML(t *testing.T) {
+
+	f, err := ParseFile("test-fixtures/failing_test.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := Run(f, nil)
+	if len(results) != 1 || results[0].Passed() {
+		t.Fatalf("expected a single failed result but got %v", results)
+	}
+
+	expected := []string{
+		"TaskGroups[api].Count == 3: got 2",
+		"TaskGroups[web].Count == 1: TaskGroups[web].Count: element web not found",
+	}
+	if fmt.Sprint(results[0].Failures) != fmt.Sprint(expected) {
+		t.Fatalf("expected failures %v but got %v", expected, results[0].Failures)
+	}
+}
+
+func TestJobTest_RunIgnoresDefaultVarFile(t *testing.T) {
+
+	// A levant.yaml in the working directory must not be loaded for tests which
+	// do not declare any variable files.
+	dir := t.TempDir()
+	files := map[string]string{
+		"levant.yaml":   "job_name: [",
+		"job.nomad":     "job \"[[ .job_name ]]\" {\n  group \"app\" {\n    task \"app\" {\n      driver = \"docker\"\n    }\n  }\n}\n",
+		"job_test.yaml": "tests:\n  - name: vars\n    vars:\n      job_name: example\n    assert:\n      - Name == example\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	f, err := ParseFile("job_test.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := Run(f, nil)
+	if len(results) != 1 || !results[0].Passed() {
+		t.Fatalf("expected a single passed result but got %v", results)
+	}
+}
+
+func TestJobTest_parseAssertion(t *testing.T) {
+
+	cases := []struct {
+		input    string
+		path     string
+		length   bool
+		operator string
+		value    interface{}
+	}{
+		{`Name == "a == b"`, "Name", false, "==", "a == b"},
+		{`TaskGroups[api].Count >= 3`, "TaskGroups[api].Count", false, ">=", float64(3)},
+		{`len(Datacenters) != 0`, "Datacenters", true, "!=", float64(0)},
+		{`Meta[a>b] == true`, "Meta[a>b]", false, "==", true},
+		{`Type == service`, "Type", false, "==", "service"},
+	}
+
+	for _, c := range cases {
+		a, err := parseAssertion(c.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.path != c.path || a.length != c.length || a.operator != c.operator || a.value != c.value {
+			t.Fatalf("unexpected assertion for %q: %+v", c.input, a)
+		}
+	}
+
+	if _, err := parseAssertion("Name"); err == nil {
+		t.Fatal("expected error for assertion without an operator")
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package jobtest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/levant/helper"
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/hashicorp/levant/template"
+)
+
+// Result is the outcome of a single template test.
+type Result struct {
+	File string
+	Test string
+
+	// Failures describe each failed assertion, or the rendering error.
+	Failures []string
+}
+
+// Passed returns whether the test passed.
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Run runs the tests within the file whose names match the filter, which
+// matches all tests if nil. Templates are rendered using the test fixtures
+// in place of all external lookups, so tests make no network calls.
+func Run(f *File, filter *regexp.Regexp) []*Result {
+
+	var results []*Result
+
+	for _, t := range f.Tests {
+		if filter != nil && !filter.MatchString(t.Name) {
+			continue
+		}
+		results = append(results, runTest(f, t))
+	}
+	return results
+}
+
+func runTest(f *File, t *Test) *Result {
+
+	r := &Result{File: f.Path, Test: t.Name}
+
+	// Tests only use the variable files they declare, so that the result does
+	// not depend on the directory the tests are run from.
+	config := &structs.TemplateConfig{
+		TemplateFile:          f.Template,
+		TemplatePaths:         f.TemplatePaths,
+		VariableFiles:         f.VariableFiles,
+		VariableSchemaFile:    f.VariableSchemaFile,
+		NoDefaultVariableFile: true,
+		ListMergeStrategy:     helper.ListMergeReplace,
+	}
+
+	fixtures := &template.Fixtures{
+		ConsulKV: t.ConsulKV,
+		Env:      t.Env,
+		Files:    t.Files,
+	}
+
+	vars := make(map[string]interface{}, len(t.Vars))
+	for k, v := range t.Vars {
+		vars[k] = v
+	}
+
+	_, job, err := template.RenderWithFixtures(config, fixtures, &vars)
+
+	if t.ExpectError != "" {
+		switch {
+		case err == nil:
+			r.Failures = append(r.Failures, fmt.Sprintf("expected error containing %q", t.ExpectError))
+		case !strings.Contains(err.Error(), t.ExpectError):
+			r.Failures = append(r.Failures, fmt.Sprintf("expected error containing %q but got: %v", t.ExpectError, err))
+		}
+		return r
+	}
+
+	if err != nil {
+		r.Failures = append(r.Failures, fmt.Sprintf("unable to render template: %v", err))
+		return r
+	}
+
+	for _, s := range t.Assert {
+		a, err := parseAssertion(s)
+		if err == nil {
+			err = a.check(job)
+		}
+		if err != nil {
+			r.Failures = append(r.Failures, fmt.Sprintf("%s: %v", s, err))
+		}
+	}
+	return r
+}
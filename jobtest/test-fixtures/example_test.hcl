@@ -0,0 +1,49 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: MPL-2.0
+
+var_files = ["example.yaml"]
+
+test "defaults" {
+  consul_kv = {
+    "service/api/image" = "api:1.0.0"
+  }
+  env = {
+    REGION = "eu-west-1"
+  }
+  files = {
+    "config/api.conf" = "port = 8080"
+  }
+
+  assert = [
+    "Name == example",
+    "Type == \"service\"",
+    "Meta.region == eu-west-1",
+    "Update.HealthyDeadline == 5m",
+    "TaskGroups[api].Count == 1",
+    "TaskGroups[api].Tasks[server].Config.image == \"api:1.0.0\"",
+    "len(TaskGroups[api].Tasks[0].Templates) == 1",
+    "TaskGroups[api].Tasks[server].Templates[0].EmbeddedTmpl != null",
+  ]
+}
+
+test "scaled" {
+  vars = {
+    count = 3
+  }
+  consul_kv = {
+    "service/api/image" = "api:1.0.0"
+  }
+  files = {
+    "config/api.conf" = ""
+  }
+
+  assert = [
+    "TaskGroups[api].Count == 3",
+    "TaskGroups[api].Count > 2",
+    "Meta.region == \"\"",
+  ]
+}
+
+test "missing consul key" {
+  expect_error = "Consul KV not found"
+}
@@ -11,16 +11,17 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func (l *levantDeployment) autoRevert(dep *nomad.Deployment) {
+// autoRevert watches the deployment triggered by Nomad to revert the job after
+// the passed deployment failed, returning whether the revert was successful.
+func (l *levantDeployment) autoRevert(dep *nomad.Deployment) bool {
 
 	// Setup a loop in order to retry a race condition whereby Levant may query
 	// the latest deployment (auto-revert dep) before it has been started.
-	i := 0
 	for i := 0; i < 5; i++ {
 		revertDep, _, err := l.nomad.Jobs().LatestDeployment(dep.JobID, &api.QueryOptions{Namespace: dep.Namespace})
 		if err != nil {
 			log.Error().Msgf("levant/auto_revert: unable to query latest deployment of job %s", dep.JobID)
-			return
+			return false
 		}
 
 		// Check whether we have got the original deployment ID as a return from
@@ -36,19 +37,18 @@ func (l *levantDeployment) autoRevert(dep *nomad.Deployment) {
 
 		if success {
 			log.Info().Msgf("levant/auto_revert: auto-revert of job %s was successful", dep.JobID)
-			break
+			return true
 		}
 
 		log.Error().Msgf("levant/auto_revert: auto-revert of job %s failed; POTENTIAL OUTAGE SITUATION", dep.JobID)
 		l.checkFailedDeployment(&revertDep.ID)
-		break
+		return false
 	}
 
 	// At this point we have not been able to get the latest deploymentID that
 	// is different from the original so we can't perform auto-revert checking.
-	if i == 5 {
-		log.Error().Msgf("levant/auto_revert: unable to check auto-revert of job %s", dep.JobID)
-	}
+	log.Error().Msgf("levant/auto_revert: unable to check auto-revert of job %s", dep.JobID)
+	return false
 }
 
 // checkAutoRevert inspects a Nomad deployment to determine if any TashGroups
@@ -5,10 +5,13 @@ package levant
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/levant/client"
+	nomadHelper "github.com/hashicorp/levant/helper/nomad"
 	"github.com/hashicorp/levant/levant/structs"
 	nomad "github.com/hashicorp/nomad/api"
 	"github.com/pkg/errors"
@@ -82,6 +85,15 @@ func TriggerDeployment(config *DeployConfig, nomadClient *nomad.Client) bool {
 		return false
 	}
 
+	// Nomad considers the job deployed once allocations are running or healthy
+	// but this does not mean the services are registered and serving traffic.
+	if config.Deploy.WaitConsulHealthy {
+		if healthy := levantDep.serviceHealthChecker(); !healthy {
+			log.Error().Msg("levant/deploy: job services failed to become healthy")
+			return false
+		}
+	}
+
 	log.Info().Msg("levant/deploy: job deployment successful")
 	return true
 }
@@ -114,6 +126,13 @@ func (l *levantDeployment) preDeployValidate() (success bool) {
 // is monitored to determine the eventual state.
 func (l *levantDeployment) deploy() (success bool) {
 
+	if l.config.Deploy.CapacityCheck {
+		if err := l.capacityPreflight(); err != nil {
+			log.Error().Err(err).Msg("levant/deploy: capacity preflight check failed; job will not be registered")
+			return
+		}
+	}
+
 	log.Info().Msgf("levant/deploy: triggering a deployment")
 
 	eval, _, err := l.nomad.Jobs().Register(l.config.Template.Job, nil)
@@ -140,7 +159,7 @@ func (l *levantDeployment) deploy() (success bool) {
 		// failure in an evaluation means no allocs will be placed so we exit here.
 		err = l.evaluationInspector(&eval.EvalID)
 		if err != nil {
-			log.Error().Err(err).Msg("levant/deploy: something")
+			log.Error().Err(err).Msg("levant/deploy: evaluation inspection failed")
 			return
 		}
 	}
@@ -255,10 +274,28 @@ func (l *levantDeployment) evaluationInspector(evalID *string) error {
 				}
 			}
 
-			// Do not return an error here; there could well be information from
-			// Nomad detailing filtered nodes but the deployment will still be
-			// successful. GH-220.
-			return nil
+			// Do not return an error here unless strict placement has been
+			// requested; there could well be information from Nomad detailing
+			// filtered nodes but the deployment will still be successful. GH-220.
+			if !l.config.Deploy.StrictPlacement {
+				return nil
+			}
+
+			for group, metrics := range evalInfo.FailedTGAllocs {
+				for _, m := range allocationMetricSummary(metrics) {
+					log.Error().Msgf("levant/deploy: task group %s placement metrics: %s", group, m)
+				}
+			}
+
+			// If the operator has asked to wait for the blocked evaluation, give
+			// the cluster a chance to free up capacity before failing.
+			if evalInfo.BlockedEval != "" && l.config.Deploy.BlockedEvalTimeout > 0 {
+				return l.waitForBlockedEval(evalInfo.BlockedEval,
+					time.Duration(l.config.Deploy.BlockedEvalTimeout)*time.Second)
+			}
+
+			return fmt.Errorf("evaluation %s failed to place allocations for %v task group(s)",
+				*evalID, len(evalInfo.FailedTGAllocs))
 
 		default:
 			time.Sleep(1 * time.Second)
@@ -267,6 +304,117 @@ func (l *levantDeployment) evaluationInspector(evalID *string) error {
 	}
 }
 
+// waitForBlockedEval waits for a blocked evaluation to be unblocked by the
+// scheduler and have all of its allocations placed. An error is returned if
+// this does not happen before the timeout is reached.
+func (l *levantDeployment) waitForBlockedEval(evalID string, timeout time.Duration) error {
+
+	log.Info().Msgf("levant/deploy: waiting up to %v for blocked evaluation %s to resolve", timeout, evalID)
+
+	deadline := time.After(timeout)
+
+	q := nomadHelper.GenerateBlockingQueryOptions(l.config.Template.Job.Namespace)
+	q.WaitTime = 5 * time.Second
+
+	for {
+		select {
+		case <-deadline:
+			return fmt.Errorf("timeout reached waiting for blocked evaluation %s to resolve", evalID)
+		default:
+		}
+
+		evalInfo, meta, err := l.nomad.Evaluations().Info(evalID, q)
+		if err != nil {
+			return err
+		}
+
+		if meta.LastIndex <= q.WaitIndex {
+			continue
+		}
+		q.WaitIndex = meta.LastIndex
+
+		switch evalInfo.Status {
+		case nomad.EvalStatusComplete:
+			if len(evalInfo.FailedTGAllocs) == 0 {
+				log.Info().Msgf("levant/deploy: blocked evaluation %s has been resolved", evalID)
+				return nil
+			}
+
+			// The scheduler was still unable to place all allocations so a new
+			// blocked evaluation will have been created which we follow.
+			if evalInfo.BlockedEval == "" {
+				return fmt.Errorf("blocked evaluation %s completed with placement failures", evalID)
+			}
+			log.Debug().Msgf("levant/deploy: blocked evaluation %s superseded by %s", evalID, evalInfo.BlockedEval)
+			evalID = evalInfo.BlockedEval
+			q.WaitIndex = 1
+
+		case nomad.EvalStatusFailed, nomad.EvalStatusCancelled:
+			return fmt.Errorf("blocked evaluation %s has status %s", evalID, evalInfo.Status)
+
+		default:
+			log.Debug().Msgf("levant/deploy: blocked evaluation %s has status %s", evalID, evalInfo.Status)
+		}
+	}
+}
+
+// allocationMetricSummary builds a list of human readable descriptions of an
+// allocation metric, detailing why the scheduler was unable to place the
+// allocations of a task group.
+func allocationMetricSummary(metrics *nomad.AllocationMetric) []string {
+
+	out := []string{fmt.Sprintf("nodes evaluated %v, nodes filtered %v, nodes exhausted %v, nodes in pool %v",
+		metrics.NodesEvaluated, metrics.NodesFiltered, metrics.NodesExhausted, metrics.NodesInPool)}
+
+	for _, dc := range sortedMapKeys(metrics.NodesAvailable) {
+		out = append(out, fmt.Sprintf("datacenter \"%s\" has %v available nodes", dc, metrics.NodesAvailable[dc]))
+	}
+	for _, c := range sortedMapKeys(metrics.ClassFiltered) {
+		out = append(out, fmt.Sprintf("class \"%s\" filtered %v nodes", c, metrics.ClassFiltered[c]))
+	}
+	for _, c := range sortedMapKeys(metrics.ConstraintFiltered) {
+		out = append(out, fmt.Sprintf("constraint \"%s\" filtered %v nodes", c, metrics.ConstraintFiltered[c]))
+	}
+	for _, c := range sortedMapKeys(metrics.ClassExhausted) {
+		out = append(out, fmt.Sprintf("class \"%s\" exhausted on %v nodes", c, metrics.ClassExhausted[c]))
+	}
+	for _, d := range sortedMapKeys(metrics.DimensionExhausted) {
+		out = append(out, fmt.Sprintf("dimension \"%s\" exhausted on %v nodes", d, metrics.DimensionExhausted[d]))
+	}
+	for _, q := range metrics.QuotaExhausted {
+		out = append(out, fmt.Sprintf("quota limit \"%s\" exhausted", q))
+	}
+
+	// Nomad replaced the Scores map with ScoreMetaData, so only fall back to
+	// the older field if the newer one is not populated.
+	if len(metrics.ScoreMetaData) > 0 {
+		for _, s := range metrics.ScoreMetaData {
+			out = append(out, fmt.Sprintf("node %s scored %.3f", s.NodeID, s.NormScore))
+		}
+	} else {
+		for _, s := range sortedMapKeys(metrics.Scores) {
+			out = append(out, fmt.Sprintf("score \"%s\" is %.3f", s, metrics.Scores[s]))
+		}
+	}
+
+	if metrics.CoalescedFailures > 0 {
+		out = append(out, fmt.Sprintf("%v additional allocations failed to place", metrics.CoalescedFailures))
+	}
+
+	return out
+}
+
+// sortedMapKeys returns the keys of the passed map in sorted order so that
+// output built from the map is stable.
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (l *levantDeployment) deploymentWatcher(depID string) (success bool) {
 
 	var canaryChan chan interface{}
@@ -381,8 +529,9 @@ func (l *levantDeployment) canaryAutoPromote(depID string, waitTime int, shutdow
 }
 
 // checkCanaryDeploymentHealth is used to check the health status of each
-// task-group within a canary deployment.
-func (l *levantDeployment) checkCanaryDeploymentHealth(depID string) (healthy bool) {
+// task-group within a canary deployment. If groups are passed, only those
+// task-groups are checked.
+func (l *levantDeployment) checkCanaryDeploymentHealth(depID string, groups ...string) (healthy bool) {
 
 	var unhealthy int
 
@@ -395,6 +544,10 @@ func (l *levantDeployment) checkCanaryDeploymentHealth(depID string) (healthy bo
 	// Iterate over each task in the deployment to determine its health status. If an
 	// unhealthy task is found, increment the unhealthy counter.
 	for taskName, taskInfo := range dep.TaskGroups {
+		if len(groups) > 0 && !slices.Contains(groups, taskName) {
+			continue
+		}
+
 		// skip any task groups which are not configured for canary deployments
 		if taskInfo.DesiredCanaries == 0 {
 			log.Debug().Msgf("levant/deploy: task %s has no desired canaries, skipping health checks in deployment %s", taskName, depID)
@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"reflect"
+	"testing"
+
+	nomad "github.com/hashicorp/nomad/api"
+)
+
+func TestDeploy_allocationMetricSummary(t *testing.T) {
+
+	cases := []struct {
+		Metrics  *nomad.AllocationMetric
+		Expected []string
+	}{
+		{
+			&nomad.AllocationMetric{},
+			[]string{"nodes evaluated 0, nodes filtered 0, nodes exhausted 0, nodes in pool 0"},
+		},
+		{
+			&nomad.AllocationMetric{
+				NodesEvaluated:     3,
+				NodesFiltered:      1,
+				NodesExhausted:     2,
+				NodesInPool:        3,
+				NodesAvailable:     map[string]int{"dc2": 1, "dc1": 2},
+				ConstraintFiltered: map[string]int{"${attr.kernel.name} = windows": 1},
+				DimensionExhausted: map[string]int{"memory": 2},
+				QuotaExhausted:     []string{"cpu exhausted (2000 needed > 1000 limit)"},
+				Scores:             map[string]float64{"binpack": 0.5},
+				CoalescedFailures:  4,
+			},
+			[]string{
+				"nodes evaluated 3, nodes filtered 1, nodes exhausted 2, nodes in pool 3",
+				"datacenter \"dc1\" has 2 available nodes",
+				"datacenter \"dc2\" has 1 available nodes",
+				"constraint \"${attr.kernel.name} = windows\" filtered 1 nodes",
+				"dimension \"memory\" exhausted on 2 nodes",
+				"quota limit \"cpu exhausted (2000 needed > 1000 limit)\" exhausted",
+				"score \"binpack\" is 0.500",
+				"4 additional allocations failed to place",
+			},
+		},
+		{
+			&nomad.AllocationMetric{
+				NodesEvaluated: 1,
+				Scores:         map[string]float64{"binpack": 0.5},
+				ScoreMetaData:  []*nomad.NodeScoreMeta{{NodeID: "node1", NormScore: 0.25}},
+			},
+			[]string{
+				"nodes evaluated 1, nodes filtered 0, nodes exhausted 0, nodes in pool 0",
+				"node node1 scored 0.250",
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		actual := allocationMetricSummary(tc.Metrics)
+		if !reflect.DeepEqual(actual, tc.Expected) {
+			t.Fatalf("case %d: got %#v, expected %#v", i, actual, tc.Expected)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hashicorp/levant/client"
+	"github.com/hashicorp/levant/helper"
+	"github.com/hashicorp/levant/levant/structs"
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/rs/zerolog/log"
+)
+
+// RegisteredJobDiff returns a unified diff between the job currently
+// registered with the Nomad cluster and the passed rendered job. Both jobs are
+// canonicalized and compared as JSON, ignoring the fields which are set by
+// the cluster such as the version and status. If the job is not registered
+// the diff shows the whole job as added, and if there are no differences an
+// empty string is returned.
+func RegisteredJobDiff(config *structs.ClientConfig, job *nomad.Job) (string, error) {
+
+	nomadClient, err := client.NewNomadClient(config.Addr)
+	if err != nil {
+		return "", err
+	}
+
+	q := &nomad.QueryOptions{AllowStale: config.AllowStale}
+	if job.Namespace != nil {
+		q.Namespace = *job.Namespace
+	}
+	if job.Region != nil {
+		q.Region = *job.Region
+	}
+
+	var registered string
+
+	rJob, _, err := nomadClient.Jobs().Info(*job.ID, q)
+
+	// This is a hack due to GH-1849; we check the error string for 404, which
+	// indicates the job is not registered.
+	if err != nil && strings.Contains(err.Error(), "404") {
+		log.Info().Msgf("levant/diff: job %s is not registered, showing the whole job as added", *job.ID)
+	} else if err != nil {
+		return "", err
+	} else {
+		if registered, err = diffableJob(rJob); err != nil {
+			return "", err
+		}
+	}
+
+	rendered, err := diffableJob(job)
+	if err != nil {
+		return "", err
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(registered),
+		B:        difflib.SplitLines(rendered),
+		FromFile: "registered",
+		ToFile:   "rendered",
+		Context:  3,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if diff == "" {
+		log.Info().Msgf("levant/diff: job %s matches the registered job", *job.ID)
+	}
+
+	// The diff can include the values of sensitive variables.
+	return helper.Redact(diff), nil
+}
+
+// diffableJob returns the canonicalized JSON form of a copy of the job, with
+// the fields set by the cluster removed.
+func diffableJob(job *nomad.Job) (string, error) {
+
+	src, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+
+	cp := &nomad.Job{}
+	if err := json.Unmarshal(src, cp); err != nil {
+		return "", err
+	}
+
+	cp.Canonicalize()
+
+	cp.Status = nil
+	cp.StatusDescription = nil
+	cp.Stable = nil
+	cp.Version = nil
+	cp.SubmitTime = nil
+	cp.CreateIndex = nil
+	cp.ModifyIndex = nil
+	cp.JobModifyIndex = nil
+
+	out, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out) + "\n", nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"testing"
+
+	nomad "github.com/hashicorp/nomad/api"
+)
+
+func TestDiff_diffableJob(t *testing.T) {
+
+	rendered := nomad.NewServiceJob("example", "example", "global", 50)
+	rendered.AddTaskGroup(nomad.NewTaskGroup("cache", 1))
+
+	// The registered job includes fields set by the cluster, which are not
+	// included when comparing the jobs.
+	registered := nomad.NewServiceJob("example", "example", "global", 50)
+	registered.AddTaskGroup(nomad.NewTaskGroup("cache", 1))
+	registered.Canonicalize()
+	registered.Version = pointerOf(uint64(3))
+	registered.Status = pointerOf("running")
+	registered.JobModifyIndex = pointerOf(uint64(42))
+
+	a, err := diffableJob(registered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := diffableJob(rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Fatalf("expected jobs to match but got:\n%s\n%s", a, b)
+	}
+
+	// The passed job is not modified.
+	if rendered.Priority == nil || rendered.Region == nil || rendered.Version != nil {
+		t.Fatalf("expected rendered job to be unmodified")
+	}
+
+	rendered.TaskGroups[0].Count = pointerOf(3)
+	if b, err = diffableJob(rendered); err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Fatal("expected jobs with different counts to differ")
+	}
+}
+
+func pointerOf[T any](v T) *T { return &v }
@@ -58,6 +58,7 @@ func (l *levantDeployment) dispatch(job string, metaMap map[string]string, paylo
 	// In order to correctly run the jobStatusChecker we need to correctly
 	// assign the dispatched job ID/Name based on the invoked job.
 	l.config = &DeployConfig{
+		Deploy: &structs.DeployConfig{},
 		Template: &structs.TemplateConfig{
 			Job: &nomad.Job{
 				ID:   &eval.DispatchedJobID,
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"fmt"
+
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/rs/zerolog/log"
+)
+
+// TriggerFail provides the main entry point into manually failing a running
+// deployment. If failing the deployment causes Nomad to revert the job, the
+// auto-revert deployment is watched until it completes.
+func TriggerFail(config *DeployConfig, target string, nomadClient *nomad.Client) bool {
+
+	levantDep, dep, err := newWatchedDeployment(config, target, nomadClient)
+	if err != nil {
+		log.Error().Err(err).Msg("levant/fail: unable to setup Levant fail")
+		return false
+	}
+
+	if dep.Status != jobStatusRunning {
+		log.Error().Err(fmt.Errorf("deployment has status %s", dep.Status)).
+			Msgf("levant/fail: unable to fail deployment %s", dep.ID)
+		return false
+	}
+
+	if success := levantDep.fail(dep); !success {
+		log.Error().Msgf("levant/fail: fail of deployment %s was unsuccessful", dep.ID)
+		return false
+	}
+
+	log.Info().Msgf("levant/fail: deployment %s has been failed", dep.ID)
+	return true
+}
+
+// fail marks the deployment as failed and tracks any resulting auto-revert,
+// returning false if the revert deployment does not succeed.
+func (l *levantDeployment) fail(dep *nomad.Deployment) bool {
+
+	log.Info().Msgf("levant/fail: triggering fail of deployment %s", dep.ID)
+
+	resp, _, err := l.nomad.Deployments().Fail(dep.ID, nil)
+	if err != nil {
+		log.Error().Err(err).Msgf("levant/fail: unable to fail deployment %s", dep.ID)
+		return false
+	}
+
+	if resp.RevertedJobVersion == nil {
+		log.Info().Msgf("levant/fail: job %v is not configured to auto-revert and has not been reverted", dep.JobID)
+		return true
+	}
+
+	log.Info().Msgf("levant/fail: job %v has been reverted to version %v; launching auto-revert checker",
+		dep.JobID, *resp.RevertedJobVersion)
+	if success := l.autoRevert(dep); !success {
+		log.Error().Msgf("levant/fail: deployment %s has been failed but the revert of job %v did not succeed",
+			dep.ID, dep.JobID)
+		return false
+	}
+	return true
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/hashicorp/levant/client"
+	"github.com/hashicorp/levant/helper"
 	"github.com/hashicorp/levant/levant/structs"
 	nomad "github.com/hashicorp/nomad/api"
 	"github.com/rs/zerolog/log"
@@ -161,6 +162,15 @@ func logDiffObj(g, t, objName, fName, fOld, fNew string) {
 
 	var lStart, l string
 
+	// Fields whose names indicate they hold sensitive data, such as task
+	// environment variables, are masked along with any values which have been
+	// marked as sensitive during rendering.
+	if helper.IsSensitiveName(fName) {
+		fOld, fNew = helper.RedactedValue, helper.RedactedValue
+	} else {
+		fOld, fNew = helper.Redact(fOld), helper.Redact(fNew)
+	}
+
 	// We will always have at least this information to log.
 	lEnd := fmt.Sprintf("plan indicates change of %s:%s from %s to %s",
 		objName, fName, fOld, fNew)
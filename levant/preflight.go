@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"fmt"
+
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/rs/zerolog/log"
+)
+
+// capacityPreflight runs a Nomad plan of the rendered job and uses the result
+// to predict whether the scheduler will be able to place the new version of
+// the job. If the job would sit blocked waiting for capacity, a capacity
+// report is logged and an error returned so that the job is not registered.
+func (l *levantDeployment) capacityPreflight() error {
+
+	log.Info().Msg("levant/preflight: running capacity preflight check")
+
+	resp, _, err := l.nomad.Jobs().Plan(l.config.Template.Job, false, nil)
+	if err != nil {
+		return fmt.Errorf("unable to run a job plan: %v", err)
+	}
+
+	report := planCapacityReport(resp)
+	if len(report) == 0 {
+		log.Info().Msg("levant/preflight: cluster has capacity to place all allocations of job")
+		return nil
+	}
+
+	for _, line := range report {
+		log.Error().Msgf("levant/preflight: %s", line)
+	}
+
+	return fmt.Errorf("cluster does not have capacity to place %v task group(s)", len(resp.FailedTGAllocs))
+}
+
+// planCapacityReport inspects a job plan response and builds a report of any
+// task groups that the scheduler would be unable to place. An empty report
+// means the job can be placed in full.
+func planCapacityReport(resp *nomad.JobPlanResponse) []string {
+
+	var report []string
+
+	if len(resp.FailedTGAllocs) == 0 {
+		return report
+	}
+
+	var desired map[string]*nomad.DesiredUpdates
+	if resp.Annotations != nil {
+		desired = resp.Annotations.DesiredTGUpdates
+	}
+
+	for _, group := range sortedMapKeys(resp.FailedTGAllocs) {
+
+		// Canary allocations are placed in addition to the currently running
+		// allocations, so the cluster needs headroom for them on top of the
+		// existing usage of the job.
+		if d, ok := desired[group]; ok && d.Canary > 0 {
+			report = append(report, fmt.Sprintf("task group %s requires %v canary allocation(s) but the cluster does not have the headroom to place them",
+				group, d.Canary))
+		} else if ok && d.Place > 0 {
+			report = append(report, fmt.Sprintf("task group %s requires %v new allocation(s) but the cluster does not have the capacity to place them",
+				group, d.Place))
+		} else {
+			report = append(report, fmt.Sprintf("task group %s failed to place allocations", group))
+		}
+
+		for _, m := range allocationMetricSummary(resp.FailedTGAllocs[group]) {
+			report = append(report, fmt.Sprintf("task group %s placement metrics: %s", group, m))
+		}
+	}
+
+	// The plan creates blocked evaluations for any placements which cannot be
+	// made; these are what the job would sit waiting on if registered.
+	var blocked int
+	for _, eval := range resp.CreatedEvals {
+		if eval.Status == nomad.EvalStatusBlocked {
+			blocked++
+		}
+	}
+	if blocked > 0 {
+		report = append(report, fmt.Sprintf("registering the job would create %v blocked evaluation(s)", blocked))
+	}
+
+	return report
+}
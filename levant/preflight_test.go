@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"reflect"
+	"testing"
+
+	nomad "github.com/hashicorp/nomad/api"
+)
+
+func TestPreflight_planCapacityReport(t *testing.T) {
+
+	cases := []struct {
+		Response *nomad.JobPlanResponse
+		Expected []string
+	}{
+		{
+			&nomad.JobPlanResponse{},
+			nil,
+		},
+		{
+			&nomad.JobPlanResponse{
+				Annotations: &nomad.PlanAnnotations{
+					DesiredTGUpdates: map[string]*nomad.DesiredUpdates{
+						"cache": {Canary: 2},
+						"web":   {Place: 3},
+					},
+				},
+				FailedTGAllocs: map[string]*nomad.AllocationMetric{
+					"web":   {NodesEvaluated: 2, NodesExhausted: 2},
+					"cache": {NodesEvaluated: 2, NodesExhausted: 2},
+				},
+				CreatedEvals: []*nomad.Evaluation{
+					{Status: nomad.EvalStatusBlocked},
+				},
+			},
+			[]string{
+				"task group cache requires 2 canary allocation(s) but the cluster does not have the headroom to place them",
+				"task group cache placement metrics: nodes evaluated 2, nodes filtered 0, nodes exhausted 2, nodes in pool 0",
+				"task group web requires 3 new allocation(s) but the cluster does not have the capacity to place them",
+				"task group web placement metrics: nodes evaluated 2, nodes filtered 0, nodes exhausted 2, nodes in pool 0",
+				"registering the job would create 1 blocked evaluation(s)",
+			},
+		},
+		{
+			&nomad.JobPlanResponse{
+				FailedTGAllocs: map[string]*nomad.AllocationMetric{
+					"web": {NodesEvaluated: 1},
+				},
+			},
+			[]string{
+				"task group web failed to place allocations",
+				"task group web placement metrics: nodes evaluated 1, nodes filtered 0, nodes exhausted 0, nodes in pool 0",
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		actual := planCapacityReport(tc.Response)
+		if !reflect.DeepEqual(actual, tc.Expected) {
+			t.Fatalf("case %d: got %#v, expected %#v", i, actual, tc.Expected)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/rs/zerolog/log"
+)
+
+// TriggerPromote provides the main entry point into promoting the canaries of
+// a running deployment. If groups are passed only the canaries of those task
+// groups are promoted. Unless force is set, the canaries are checked to be
+// healthy before promoting. Once promoted, the deployment is watched until it
+// completes, or until the promoted task groups are healthy if other task groups
+// are still awaiting promotion.
+func TriggerPromote(config *DeployConfig, target string, groups []string, force bool, nomadClient *nomad.Client) bool {
+
+	levantDep, dep, err := newWatchedDeployment(config, target, nomadClient)
+	if err != nil {
+		log.Error().Err(err).Msg("levant/promote: unable to setup Levant promote")
+		return false
+	}
+
+	if err := checkPromotable(dep, groups); err != nil {
+		log.Error().Err(err).Msgf("levant/promote: unable to promote deployment %s", dep.ID)
+		return false
+	}
+
+	if !force {
+		if healthy := levantDep.checkCanaryDeploymentHealth(dep.ID, groups...); !healthy {
+			log.Error().Msgf("levant/promote: the canary deployment %s has unhealthy allocations, unable to promote", dep.ID)
+			return false
+		}
+	}
+
+	if success := levantDep.promote(dep, groups); !success {
+		log.Error().Msg("levant/promote: job deployment failed")
+		return false
+	}
+
+	log.Info().Msg("levant/promote: job deployment successful")
+	return true
+}
+
+// promote promotes the canaries of the deployment and watches the deployment
+// to completion. When only some task groups are promoted while others still
+// have canaries awaiting promotion, the promoted task groups are watched until
+// they are healthy.
+func (l *levantDeployment) promote(dep *nomad.Deployment, groups []string) bool {
+
+	var err error
+
+	if len(groups) > 0 {
+		log.Info().Msgf("levant/promote: triggering promote of task groups %v in deployment %s", groups, dep.ID)
+		_, _, err = l.nomad.Deployments().PromoteGroups(dep.ID, groups, nil)
+	} else {
+		log.Info().Msgf("levant/promote: triggering promote of deployment %s", dep.ID)
+		_, _, err = l.nomad.Deployments().PromoteAll(dep.ID, nil)
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("levant/promote: unable to promote deployment %s", dep.ID)
+		return false
+	}
+
+	// The deployment will not complete while other task groups have canaries
+	// awaiting promotion, so only the promoted task groups are watched.
+	if waiting := groupsAwaitingPromotion(dep, groups); len(waiting) > 0 {
+		log.Info().Msgf("levant/promote: task groups %v still have canaries awaiting promotion, watching task groups %v until healthy",
+			waiting, groups)
+		return l.groupsWatcher(dep.ID, groups)
+	}
+
+	l.config.Deploy.Canary = 0
+	return l.watch(dep)
+}
+
+// checkPromotable ensures the deployment has canaries awaiting promotion
+// within each of the passed task groups.
+func checkPromotable(dep *nomad.Deployment, groups []string) error {
+
+	if dep.Status != jobStatusRunning {
+		return fmt.Errorf("deployment has status %s", dep.Status)
+	}
+
+	if !canariesAwaitingPromotion(dep) {
+		return fmt.Errorf("deployment has no canaries awaiting promotion")
+	}
+
+	for _, name := range groups {
+		state, ok := dep.TaskGroups[name]
+		if !ok {
+			return fmt.Errorf("task group %s is not part of the deployment", name)
+		}
+		if state.DesiredCanaries == 0 || state.Promoted {
+			return fmt.Errorf("task group %s has no canaries awaiting promotion", name)
+		}
+	}
+	return nil
+}
+
+// groupsWatcher watches the deployment until all the passed task groups are
+// healthy, returning false if the deployment fails before this happens.
+func (l *levantDeployment) groupsWatcher(depID string, groups []string) bool {
+
+	q := &nomad.QueryOptions{WaitIndex: 1, AllowStale: l.config.Client.AllowStale, WaitTime: 5 * time.Second}
+
+	for {
+		dep, meta, err := l.nomad.Deployments().Info(depID, q)
+		if err != nil {
+			log.Error().Err(err).Msgf("levant/promote: unable to get info of deployment %s", depID)
+			return false
+		}
+
+		if meta.LastIndex <= q.WaitIndex {
+			continue
+		}
+		q.WaitIndex = meta.LastIndex
+
+		if cont, err := l.checkDeploymentStatus(dep, nil); err != nil {
+			return false
+		} else if !cont {
+			return true
+		}
+
+		if groupsHealthy(dep, groups) {
+			log.Info().Msgf("levant/promote: task groups %v of deployment %s are healthy", groups, depID)
+			return true
+		}
+	}
+}
+
+// groupsAwaitingPromotion returns the task groups, other than those passed,
+// which have canaries awaiting promotion.
+func groupsAwaitingPromotion(dep *nomad.Deployment, groups []string) []string {
+
+	if len(groups) == 0 {
+		return nil
+	}
+
+	var waiting []string
+	for _, name := range sortedMapKeys(dep.TaskGroups) {
+		state := dep.TaskGroups[name]
+		if state.DesiredCanaries > 0 && !state.Promoted && !slices.Contains(groups, name) {
+			waiting = append(waiting, name)
+		}
+	}
+	return waiting
+}
+
+// groupsHealthy returns whether each of the passed task groups within the
+// deployment has been promoted and has all of its desired allocations healthy.
+func groupsHealthy(dep *nomad.Deployment, groups []string) bool {
+	for _, name := range groups {
+		state, ok := dep.TaskGroups[name]
+		if !ok || (state.DesiredCanaries > 0 && !state.Promoted) || state.HealthyAllocs < state.DesiredTotal {
+			return false
+		}
+	}
+	return true
+}
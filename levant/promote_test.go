@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"testing"
+
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromote_checkPromotable(t *testing.T) {
+
+	dep := &nomad.Deployment{
+		Status: "running",
+		TaskGroups: map[string]*nomad.DeploymentState{
+			"web":   {DesiredTotal: 3, DesiredCanaries: 1},
+			"cache": {DesiredTotal: 1},
+		},
+	}
+
+	require.NoError(t, checkPromotable(dep, nil))
+	require.NoError(t, checkPromotable(dep, []string{"web"}))
+	require.EqualError(t, checkPromotable(dep, []string{"cache"}), "task group cache has no canaries awaiting promotion")
+	require.EqualError(t, checkPromotable(dep, []string{"db"}), "task group db is not part of the deployment")
+
+	dep.TaskGroups["web"].Promoted = true
+	require.EqualError(t, checkPromotable(dep, nil), "deployment has no canaries awaiting promotion")
+
+	dep.Status = "successful"
+	require.EqualError(t, checkPromotable(dep, nil), "deployment has status successful")
+}
+
+func TestPromote_groupsAwaitingPromotion(t *testing.T) {
+
+	dep := &nomad.Deployment{
+		TaskGroups: map[string]*nomad.DeploymentState{
+			"web":   {DesiredTotal: 3, DesiredCanaries: 1},
+			"api":   {DesiredTotal: 2, DesiredCanaries: 1},
+			"cache": {DesiredTotal: 1},
+			"db":    {DesiredTotal: 1, DesiredCanaries: 1, Promoted: true},
+		},
+	}
+
+	require.Empty(t, groupsAwaitingPromotion(dep, nil))
+	require.Empty(t, groupsAwaitingPromotion(dep, []string{"api", "web"}))
+	require.Equal(t, []string{"web"}, groupsAwaitingPromotion(dep, []string{"api"}))
+}
+
+func TestPromote_groupsHealthy(t *testing.T) {
+
+	dep := &nomad.Deployment{
+		TaskGroups: map[string]*nomad.DeploymentState{
+			"web": {DesiredTotal: 3, DesiredCanaries: 1, HealthyAllocs: 1},
+			"api": {DesiredTotal: 2, DesiredCanaries: 1, HealthyAllocs: 1},
+		},
+	}
+
+	require.False(t, groupsHealthy(dep, []string{"web"}))
+
+	dep.TaskGroups["web"].Promoted = true
+	require.False(t, groupsHealthy(dep, []string{"web"}))
+
+	dep.TaskGroups["web"].HealthyAllocs = 3
+	require.True(t, groupsHealthy(dep, []string{"web"}))
+	require.False(t, groupsHealthy(dep, []string{"web", "api"}))
+	require.False(t, groupsHealthy(dep, []string{"db"}))
+}
@@ -0,0 +1,322 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/levant/client"
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	checkStatusSuccess   = "success"
+	serviceProviderNomad = "nomad"
+)
+
+// jobService is a service block within the rendered job which Levant expects
+// to be registered and healthy for each running allocation of its task group.
+type jobService struct {
+	Name     string
+	Group    string
+	Provider string
+	Checks   int
+}
+
+// serviceHealthChecker waits for every service defined within the job to be
+// registered and passing its checks for all running allocations of the
+// current job version. Consul services are checked using the Consul health
+// API and Nomad services using the Nomad service and allocation check APIs.
+func (l *levantDeployment) serviceHealthChecker() bool {
+
+	services := jobServices(l.config.Template.Job)
+	if len(services) == 0 {
+		log.Info().Msg("levant/service_health: job has no services to check")
+		return true
+	}
+
+	var consulClient *consul.Client
+	for _, s := range services {
+		if s.Provider == serviceProviderNomad || consulClient != nil {
+			continue
+		}
+		c, err := client.NewConsulClient(l.config.Client.ConsulAddr)
+		if err != nil {
+			log.Error().Err(err).Msg("levant/service_health: unable to setup Consul client")
+			return false
+		}
+		consulClient = c
+	}
+
+	timeout := time.Duration(l.config.Deploy.ConsulHealthyTimeout) * time.Second
+	deadline := time.After(timeout)
+
+	log.Info().Msgf("levant/service_health: waiting up to %v for %v service(s) to become healthy",
+		timeout, len(services))
+
+	for {
+		unhealthy, err := l.unhealthyServices(services, consulClient)
+		if err != nil {
+			log.Error().Err(err).Msg("levant/service_health: unable to check service health")
+			return false
+		}
+
+		if len(unhealthy) == 0 {
+			log.Info().Msg("levant/service_health: all job services are healthy")
+			return true
+		}
+
+		select {
+		case <-deadline:
+			for _, u := range unhealthy {
+				log.Error().Msgf("levant/service_health: %s", u)
+			}
+			return false
+		case <-time.After(5 * time.Second):
+			for _, u := range unhealthy {
+				log.Debug().Msgf("levant/service_health: %s", u)
+			}
+		}
+	}
+}
+
+// unhealthyServices checks the health of each job service against the
+// expected number of instances of its task group, returning a description of
+// each service which does not yet have all expected instances healthy.
+func (l *levantDeployment) unhealthyServices(services []*jobService, consulClient *consul.Client) ([]string, error) {
+
+	groupAllocs, expected, err := l.groupInstances()
+	if err != nil {
+		return nil, err
+	}
+
+	var unhealthy []string
+
+	for _, s := range services {
+
+		// Task groups scaled to zero have no instances to check.
+		if expected[s.Group] == 0 {
+			continue
+		}
+
+		var healthy int
+		if allocs := groupAllocs[s.Group]; len(allocs) > 0 {
+			if s.Provider == serviceProviderNomad {
+				healthy, err = l.healthyNomadInstances(s, allocs)
+			} else {
+				healthy, err = healthyConsulInstances(consulClient, s, allocs)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if healthy < expected[s.Group] {
+			unhealthy = append(unhealthy, fmt.Sprintf("service %s in group %s has %v/%v healthy instances",
+				s.Name, s.Group, healthy, expected[s.Group]))
+		}
+	}
+
+	return unhealthy, nil
+}
+
+// groupInstances returns the IDs of the running allocations of the current
+// job version, along with the number of instances expected, keyed by task
+// group name.
+func (l *levantDeployment) groupInstances() (map[string][]string, map[string]int, error) {
+
+	q := &nomad.QueryOptions{AllowStale: l.config.Client.AllowStale}
+	if l.config.Template.Job.Namespace != nil {
+		q.Namespace = *l.config.Template.Job.Namespace
+	}
+
+	job, _, err := l.nomad.Jobs().Info(*l.config.Template.Job.ID, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allocs, _, err := l.nomad.Jobs().Allocations(*l.config.Template.Job.ID, false, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	running := make(map[string][]string)
+	for _, alloc := range allocs {
+		if alloc.JobVersion != *job.Version || alloc.DesiredStatus != "run" ||
+			alloc.ClientStatus != nomad.AllocClientStatusRunning {
+			continue
+		}
+		running[alloc.TaskGroup] = append(running[alloc.TaskGroup], alloc.ID)
+	}
+	return running, expectedGroupInstances(job, allocs), nil
+}
+
+// expectedGroupInstances returns the number of instances expected for each
+// task group of the registered job. This is the group count, other than for
+// system jobs where it is the number of allocations placed by the scheduler
+// for the current job version. At least one instance is always expected for
+// system jobs, so Levant waits for the first allocation to be placed.
+func expectedGroupInstances(job *nomad.Job, allocs []*nomad.AllocationListStub) map[string]int {
+
+	system := job.Type != nil && (*job.Type == nomad.JobTypeSystem || *job.Type == nomad.JobTypeSysbatch)
+
+	placed := make(map[string]int)
+	for _, alloc := range allocs {
+		if job.Version != nil && alloc.JobVersion == *job.Version && alloc.DesiredStatus == "run" {
+			placed[alloc.TaskGroup]++
+		}
+	}
+
+	out := make(map[string]int, len(job.TaskGroups))
+	for _, group := range job.TaskGroups {
+		switch {
+		case system:
+			out[*group.Name] = max(placed[*group.Name], 1)
+		case group.Count != nil:
+			out[*group.Name] = *group.Count
+		default:
+			out[*group.Name] = 1
+		}
+	}
+	return out
+}
+
+// healthyNomadInstances counts the allocations which have registered the
+// service with Nomad and have all of the service checks passing.
+func (l *levantDeployment) healthyNomadInstances(s *jobService, allocs []string) (int, error) {
+
+	q := &nomad.QueryOptions{AllowStale: l.config.Client.AllowStale}
+	if l.config.Template.Job.Namespace != nil {
+		q.Namespace = *l.config.Template.Job.Namespace
+	}
+
+	regs, _, err := l.nomad.Services().Get(s.Name, q)
+	if err != nil {
+		return 0, err
+	}
+
+	var healthy int
+	for _, reg := range regs {
+		if !slices.Contains(allocs, reg.AllocID) {
+			continue
+		}
+
+		if s.Checks == 0 {
+			healthy++
+			continue
+		}
+
+		checks, err := l.nomad.Allocations().Checks(reg.AllocID, q)
+		if err != nil {
+			return 0, err
+		}
+
+		passing := 0
+		for _, c := range checks {
+			if c.Service == s.Name && c.Status == checkStatusSuccess {
+				passing++
+			}
+		}
+		if passing >= s.Checks {
+			healthy++
+		}
+	}
+	return healthy, nil
+}
+
+// healthyConsulInstances counts the allocations which have registered the
+// service with Consul and have all of the service checks passing.
+func healthyConsulInstances(c *consul.Client, s *jobService, allocs []string) (int, error) {
+
+	entries, _, err := c.Health().Service(s.Name, "", true, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	// Nomad includes the allocation ID within the ID of the services it
+	// registers in Consul which allows us to ignore instances from other
+	// allocations or jobs.
+	var healthy int
+	for _, entry := range entries {
+		for _, id := range allocs {
+			if strings.Contains(entry.Service.ID, id) {
+				healthy++
+				break
+			}
+		}
+	}
+	return healthy, nil
+}
+
+// jobServices builds the list of services defined at both the group and task
+// level of the job. Service names are interpolated using the job, group and
+// task names; services using any other runtime interpolation are skipped as
+// their names cannot be determined ahead of time.
+func jobServices(job *nomad.Job) []*jobService {
+
+	var out []*jobService
+
+	// The job name defaults to the job ID when it is not set.
+	var jobName string
+	if job.Name != nil {
+		jobName = *job.Name
+	} else if job.ID != nil {
+		jobName = *job.ID
+	}
+
+	add := func(s *nomad.Service, group, task string) {
+		name := serviceName(s.Name, jobName, group, task)
+		if strings.Contains(name, "${") {
+			log.Warn().Msgf("levant/service_health: unable to interpolate service name %s; skipping health check", name)
+			return
+		}
+		out = append(out, &jobService{
+			Name:     name,
+			Group:    group,
+			Provider: s.Provider,
+			Checks:   len(s.Checks),
+		})
+	}
+
+	for _, group := range job.TaskGroups {
+		for _, s := range group.Services {
+			add(s, *group.Name, "")
+		}
+		for _, task := range group.Tasks {
+			for _, s := range task.Services {
+				add(s, *group.Name, task.Name)
+			}
+		}
+	}
+	return out
+}
+
+// serviceName returns the name the service will be registered as, mirroring
+// the default names and interpolation Nomad performs.
+func serviceName(name, job, group, task string) string {
+
+	base := fmt.Sprintf("%s-%s", job, group)
+	if task != "" {
+		base = fmt.Sprintf("%s-%s", base, task)
+	}
+
+	if name == "" {
+		return base
+	}
+
+	return strings.NewReplacer(
+		"${BASE}", base,
+		"${JOB}", job,
+		"${NOMAD_JOB_NAME}", job,
+		"${TASKGROUP}", group,
+		"${NOMAD_GROUP_NAME}", group,
+		"${TASK}", task,
+		"${NOMAD_TASK_NAME}", task,
+	).Replace(name)
+}
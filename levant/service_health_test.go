@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"reflect"
+	"testing"
+
+	nomad "github.com/hashicorp/nomad/api"
+)
+
+func TestServiceHealth_jobServices(t *testing.T) {
+
+	jobName := "example"
+	groupName := "cache"
+
+	job := &nomad.Job{
+		Name: &jobName,
+		TaskGroups: []*nomad.TaskGroup{
+			{
+				Name: &groupName,
+				Services: []*nomad.Service{
+					{Name: "${JOB}-group", Provider: "nomad"},
+				},
+				Tasks: []*nomad.Task{
+					{
+						Name: "redis",
+						Services: []*nomad.Service{
+							{Checks: []nomad.ServiceCheck{{Name: "alive"}}},
+							{Name: "${NOMAD_TASK_NAME}-metrics"},
+							{Name: "${NOMAD_ALLOC_INDEX}-redis"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expected := []*jobService{
+		{Name: "example-group", Group: "cache", Provider: "nomad"},
+		{Name: "example-cache-redis", Group: "cache", Checks: 1},
+		{Name: "redis-metrics", Group: "cache"},
+	}
+
+	actual := jobServices(job)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("got: %#v, expected %#v", actual, expected)
+	}
+}
+
+func TestServiceHealth_jobServicesDefaultName(t *testing.T) {
+
+	jobID := "example"
+	groupName := "cache"
+
+	job := &nomad.Job{
+		ID: &jobID,
+		TaskGroups: []*nomad.TaskGroup{
+			{
+				Name:     &groupName,
+				Services: []*nomad.Service{{}},
+			},
+		},
+	}
+
+	expected := []*jobService{{Name: "example-cache", Group: "cache"}}
+
+	actual := jobServices(job)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("got: %#v, expected %#v", actual, expected)
+	}
+}
+
+func TestServiceHealth_expectedGroupInstances(t *testing.T) {
+
+	web, cache, db := "web", "cache", "db"
+	three, zero := 3, 0
+	version := uint64(2)
+	service, system := nomad.JobTypeService, nomad.JobTypeSystem
+
+	allocs := []*nomad.AllocationListStub{
+		{TaskGroup: "web", JobVersion: 2, DesiredStatus: "run", ClientStatus: "running"},
+		{TaskGroup: "web", JobVersion: 2, DesiredStatus: "run", ClientStatus: "pending"},
+		{TaskGroup: "web", JobVersion: 1, DesiredStatus: "run", ClientStatus: "running"},
+		{TaskGroup: "web", JobVersion: 2, DesiredStatus: "stop", ClientStatus: "complete"},
+	}
+
+	cases := []struct {
+		job      *nomad.Job
+		expected map[string]int
+	}{
+		{
+			&nomad.Job{
+				Type:    &service,
+				Version: &version,
+				TaskGroups: []*nomad.TaskGroup{
+					{Name: &web, Count: &three},
+					{Name: &cache},
+					{Name: &db, Count: &zero},
+				},
+			},
+			map[string]int{"web": 3, "cache": 1, "db": 0},
+		},
+		{
+			&nomad.Job{
+				Type:    &system,
+				Version: &version,
+				TaskGroups: []*nomad.TaskGroup{
+					{Name: &web},
+					{Name: &cache},
+				},
+			},
+			map[string]int{"web": 2, "cache": 1},
+		},
+	}
+
+	for i, tc := range cases {
+		actual := expectedGroupInstances(tc.job, allocs)
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Fatalf("case %v: got: %#v, expected %#v", i, actual, tc.expected)
+		}
+	}
+}
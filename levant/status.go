@@ -0,0 +1,285 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/levant/client"
+	"github.com/hashicorp/levant/levant/structs"
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/rs/zerolog/log"
+)
+
+// recentFailedAllocLimit is the maximum number of failed allocations which
+// are inspected when building the status of a job.
+const recentFailedAllocLimit = 5
+
+// JobStatus is a read-only view of the current state of a job, its latest
+// deployment and recent failures.
+type JobStatus struct {
+	ID        string
+	Namespace string
+	Type      string
+	Status    string
+	Version   uint64
+	Stable    bool
+
+	// Deployment is the latest deployment of the job, which is nil if the job
+	// has never been deployed.
+	Deployment *DeploymentStatus
+
+	// Groups holds the allocation counts of each task group, sorted by name.
+	Groups []*GroupStatus
+
+	// Versions holds the known versions of the job, newest first.
+	Versions []*JobVersion
+
+	// FailedAllocations holds the most recently failed allocations of the
+	// job, newest first.
+	FailedAllocations []*FailedAllocation
+}
+
+// DeploymentStatus is the state of a Nomad deployment.
+type DeploymentStatus struct {
+	ID          string
+	JobVersion  uint64
+	Status      string
+	Description string
+}
+
+// GroupStatus holds the allocation counts of a task group, along with the
+// placement and health of the group within the latest deployment.
+type GroupStatus struct {
+	Name     string
+	Queued   int
+	Starting int
+	Running  int
+	Failed   int
+	Complete int
+	Lost     int
+
+	// The following are only set when the group is part of the latest
+	// deployment.
+	Desired          int
+	Placed           int
+	Healthy          int
+	Unhealthy        int
+	DesiredCanaries  int
+	PlacedCanaries   int
+	Promoted         bool
+	AutoRevert       bool
+	InLastDeployment bool
+}
+
+// JobVersion is a single version of a job.
+type JobVersion struct {
+	Version   uint64
+	Stable    bool
+	Submitted time.Time
+}
+
+// FailedAllocation is an allocation which has failed, along with the reasons
+// its tasks failed.
+type FailedAllocation struct {
+	ID       string
+	Group    string
+	Modified time.Time
+	Reasons  []string
+}
+
+// GetJobStatus queries Nomad for the current status of the job.
+func GetJobStatus(config *structs.ClientConfig, jobID string) (*JobStatus, error) {
+
+	nomadClient, err := client.NewNomadClient(config.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &nomad.QueryOptions{AllowStale: config.AllowStale}
+
+	job, _, err := nomadClient.Jobs().Info(jobID, q)
+	if err != nil {
+		// This is a hack due to GH-1849; we check the error string for 404, which
+		// indicates the job is not registered.
+		if strings.Contains(err.Error(), "404") {
+			return nil, fmt.Errorf("job %s not found", jobID)
+		}
+		return nil, err
+	}
+
+	status := &JobStatus{
+		ID:        *job.ID,
+		Namespace: stringValue(job.Namespace),
+		Type:      stringValue(job.Type),
+		Status:    stringValue(job.Status),
+	}
+	if job.Version != nil {
+		status.Version = *job.Version
+	}
+	if job.Stable != nil {
+		status.Stable = *job.Stable
+	}
+
+	q.Namespace = status.Namespace
+
+	summary, _, err := nomadClient.Jobs().Summary(jobID, q)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query summary of job %s: %v", jobID, err)
+	}
+
+	dep, _, err := nomadClient.Jobs().LatestDeployment(jobID, q)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query latest deployment of job %s: %v", jobID, err)
+	}
+
+	status.Groups = groupStatuses(summary, dep)
+	if dep != nil {
+		status.Deployment = &DeploymentStatus{
+			ID:          dep.ID,
+			JobVersion:  dep.JobVersion,
+			Status:      dep.Status,
+			Description: dep.StatusDescription,
+		}
+	}
+
+	versions, _, _, err := nomadClient.Jobs().Versions(jobID, false, q)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query versions of job %s: %v", jobID, err)
+	}
+	status.Versions = jobVersions(versions)
+
+	allocs, _, err := nomadClient.Jobs().Allocations(jobID, false, q)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query allocations of job %s: %v", jobID, err)
+	}
+
+	for _, stub := range recentFailedAllocs(allocs, recentFailedAllocLimit) {
+		alloc, _, err := nomadClient.Allocations().Info(stub.ID, q)
+		if err != nil {
+			log.Error().Err(err).Msgf("levant/status: unable to query alloc %s", stub.ID)
+			continue
+		}
+		status.FailedAllocations = append(status.FailedAllocations, &FailedAllocation{
+			ID:       alloc.ID,
+			Group:    alloc.TaskGroup,
+			Modified: time.Unix(0, alloc.ModifyTime),
+			Reasons:  allocFailureReasons(alloc),
+		})
+	}
+
+	return status, nil
+}
+
+// groupStatuses merges the job summary and latest deployment into the status
+// of each task group.
+func groupStatuses(summary *nomad.JobSummary, dep *nomad.Deployment) []*GroupStatus {
+
+	groups := make(map[string]*GroupStatus)
+	group := func(name string) *GroupStatus {
+		if _, ok := groups[name]; !ok {
+			groups[name] = &GroupStatus{Name: name}
+		}
+		return groups[name]
+	}
+
+	if summary != nil {
+		for name, s := range summary.Summary {
+			g := group(name)
+			g.Queued, g.Starting, g.Running = s.Queued, s.Starting, s.Running
+			g.Failed, g.Complete, g.Lost = s.Failed, s.Complete, s.Lost
+		}
+	}
+
+	if dep != nil {
+		for name, s := range dep.TaskGroups {
+			g := group(name)
+			g.InLastDeployment = true
+			g.Desired, g.Placed = s.DesiredTotal, s.PlacedAllocs
+			g.Healthy, g.Unhealthy = s.HealthyAllocs, s.UnhealthyAllocs
+			g.DesiredCanaries, g.PlacedCanaries = s.DesiredCanaries, len(s.PlacedCanaries)
+			g.Promoted, g.AutoRevert = s.Promoted, s.AutoRevert
+		}
+	}
+
+	out := make([]*GroupStatus, 0, len(groups))
+	for _, name := range sortedMapKeys(groups) {
+		out = append(out, groups[name])
+	}
+	return out
+}
+
+// jobVersions returns the versions of the job, newest first.
+func jobVersions(jobs []*nomad.Job) []*JobVersion {
+
+	out := make([]*JobVersion, 0, len(jobs))
+	for _, job := range jobs {
+		v := &JobVersion{}
+		if job.Version != nil {
+			v.Version = *job.Version
+		}
+		if job.Stable != nil {
+			v.Stable = *job.Stable
+		}
+		if job.SubmitTime != nil {
+			v.Submitted = time.Unix(0, *job.SubmitTime)
+		}
+		out = append(out, v)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Version > out[j].Version })
+	return out
+}
+
+// recentFailedAllocs returns up to limit failed allocations, newest first.
+func recentFailedAllocs(allocs []*nomad.AllocationListStub, limit int) []*nomad.AllocationListStub {
+
+	var failed []*nomad.AllocationListStub
+	for _, alloc := range allocs {
+		if alloc.ClientStatus == nomad.AllocClientStatusFailed {
+			failed = append(failed, alloc)
+		}
+	}
+
+	sort.Slice(failed, func(i, j int) bool { return failed[i].ModifyTime > failed[j].ModifyTime })
+
+	if len(failed) > limit {
+		failed = failed[:limit]
+	}
+	return failed
+}
+
+// allocFailureReasons classifies the events of the failed tasks within an
+// allocation, in the same manner as the failure inspector.
+func allocFailureReasons(alloc *nomad.Allocation) []string {
+
+	var reasons []string
+	for _, name := range sortedMapKeys(alloc.TaskStates) {
+		task := alloc.TaskStates[name]
+		if !task.Failed {
+			continue
+		}
+		for _, event := range task.Events {
+			if desc := allocEventDescription(event); desc != "" {
+				reasons = append(reasons, fmt.Sprintf("task %s incurred event %s because %s",
+					name, strings.ToLower(event.Type), desc))
+			}
+		}
+	}
+
+	if len(reasons) == 0 && alloc.ClientDescription != "" {
+		reasons = append(reasons, alloc.ClientDescription)
+	}
+	return reasons
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"testing"
+
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus_groupStatuses(t *testing.T) {
+
+	summary := &nomad.JobSummary{
+		Summary: map[string]nomad.TaskGroupSummary{
+			"web":   {Running: 3, Failed: 1},
+			"cache": {Running: 1, Queued: 1},
+		},
+	}
+	dep := &nomad.Deployment{
+		TaskGroups: map[string]*nomad.DeploymentState{
+			"web": {
+				DesiredTotal:    3,
+				PlacedAllocs:    2,
+				HealthyAllocs:   1,
+				UnhealthyAllocs: 1,
+				DesiredCanaries: 2,
+				PlacedCanaries:  []string{"a"},
+				AutoRevert:      true,
+			},
+		},
+	}
+
+	out := groupStatuses(summary, dep)
+	require.Len(t, out, 2)
+
+	require.Equal(t, &GroupStatus{Name: "cache", Running: 1, Queued: 1}, out[0])
+	require.Equal(t, &GroupStatus{
+		Name:             "web",
+		Running:          3,
+		Failed:           1,
+		Desired:          3,
+		Placed:           2,
+		Healthy:          1,
+		Unhealthy:        1,
+		DesiredCanaries:  2,
+		PlacedCanaries:   1,
+		AutoRevert:       true,
+		InLastDeployment: true,
+	}, out[1])
+
+	require.Len(t, groupStatuses(summary, nil), 2)
+}
+
+func TestStatus_jobVersions(t *testing.T) {
+
+	out := jobVersions([]*nomad.Job{
+		{Version: pointerOf(uint64(0)), Stable: pointerOf(true), SubmitTime: pointerOf(int64(1))},
+		{Version: pointerOf(uint64(2)), Stable: pointerOf(false)},
+		{Version: pointerOf(uint64(1)), Stable: pointerOf(true)},
+	})
+
+	require.Len(t, out, 3)
+	require.Equal(t, uint64(2), out[0].Version)
+	require.False(t, out[0].Stable)
+	require.Equal(t, uint64(1), out[1].Version)
+	require.Equal(t, uint64(0), out[2].Version)
+	require.Equal(t, int64(1), out[2].Submitted.UnixNano())
+}
+
+func TestStatus_recentFailedAllocs(t *testing.T) {
+
+	allocs := []*nomad.AllocationListStub{
+		{ID: "a", ClientStatus: nomad.AllocClientStatusFailed, ModifyTime: 1},
+		{ID: "b", ClientStatus: nomad.AllocClientStatusRunning, ModifyTime: 5},
+		{ID: "c", ClientStatus: nomad.AllocClientStatusFailed, ModifyTime: 3},
+		{ID: "d", ClientStatus: nomad.AllocClientStatusFailed, ModifyTime: 2},
+	}
+
+	out := recentFailedAllocs(allocs, 2)
+	require.Len(t, out, 2)
+	require.Equal(t, "c", out[0].ID)
+	require.Equal(t, "d", out[1].ID)
+
+	require.Empty(t, recentFailedAllocs(nil, 2))
+}
+
+func TestStatus_allocFailureReasons(t *testing.T) {
+
+	alloc := &nomad.Allocation{
+		ClientDescription: "Failed tasks",
+		TaskStates: map[string]*nomad.TaskState{
+			"server": {
+				Failed: true,
+				Events: []*nomad.TaskEvent{
+					{Type: nomad.TaskReceived},
+					{Type: nomad.TaskDriverFailure, DriverError: "image not found"},
+					{Type: nomad.TaskNotRestarting},
+				},
+			},
+			"sidecar": {
+				Events: []*nomad.TaskEvent{
+					{Type: nomad.TaskKilled},
+				},
+			},
+		},
+	}
+
+	require.Equal(t, []string{
+		"task server incurred event driver failure because image not found",
+		"task server incurred event not restarting because the task exceeded restart policy",
+	}, allocFailureReasons(alloc))
+
+	// Allocations without classified task events fall back to the client
+	// description.
+	alloc.TaskStates["server"].Events = nil
+	require.Equal(t, []string{"Failed tasks"}, allocFailureReasons(alloc))
+}
@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	nomadHelper "github.com/hashicorp/levant/helper/nomad"
+	"github.com/hashicorp/levant/levant/structs"
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/rs/zerolog/log"
+)
+
+// StopConfig is the set of config structs required to run a Levant stop.
+type StopConfig struct {
+	Client   *structs.ClientConfig
+	Stop     *structs.StopConfig
+	Template *structs.TemplateConfig
+}
+
+// TriggerStop provides the main entry point into stopping a job. The job is
+// deregistered from Nomad and tracked until all of its allocations have
+// stopped.
+func TriggerStop(config *StopConfig, nomadClient *nomad.Client) bool {
+
+	levantDep, err := newLevantDeployment(&DeployConfig{
+		Client:   config.Client,
+		Deploy:   &structs.DeployConfig{},
+		Template: config.Template,
+	}, nomadClient)
+	if err != nil {
+		log.Error().Err(err).Msg("levant/stop: unable to setup Levant stop")
+		return false
+	}
+
+	if success := levantDep.stop(config.Stop); !success {
+		log.Error().Msg("levant/stop: job stop failed")
+		return false
+	}
+
+	log.Info().Msg("levant/stop: job stop successful")
+	return true
+}
+
+// stop deregisters the job and waits for its allocations to stop.
+func (l *levantDeployment) stop(config *structs.StopConfig) bool {
+
+	job := l.config.Template.Job
+
+	q := &nomad.QueryOptions{AllowStale: l.config.Client.AllowStale}
+	w := &nomad.WriteOptions{}
+	if job.Namespace != nil {
+		q.Namespace = *job.Namespace
+		w.Namespace = *job.Namespace
+	}
+
+	if _, _, err := l.nomad.Jobs().Info(*job.ID, q); err != nil {
+		// This is a hack due to GH-1849; we check the error string for 404, which
+		// indicates the job is not registered.
+		if strings.Contains(err.Error(), "404") {
+			log.Error().Msg("levant/stop: job is not registered with Nomad")
+		} else {
+			log.Error().Err(err).Msg("levant/stop: unable to query job information from Nomad")
+		}
+		return false
+	}
+
+	log.Info().Msgf("levant/stop: triggering a stop of job with purge %v and global %v", config.Purge, config.Global)
+
+	evalID, _, err := l.nomad.Jobs().DeregisterOpts(*job.ID, &nomad.DeregisterOptions{
+		Purge:  config.Purge,
+		Global: config.Global,
+	}, w)
+	if err != nil {
+		log.Error().Err(err).Msg("levant/stop: unable to deregister job with Nomad")
+		return false
+	}
+
+	// Periodic and parameterized jobs do not return an evaluation when they
+	// are deregistered.
+	if evalID != "" {
+		if err := l.evaluationInspector(&evalID); err != nil {
+			log.Error().Err(err).Msg("levant/stop: evaluation inspection failed")
+			return false
+		}
+	}
+
+	if err := l.waitForTerminalAllocs(time.Duration(config.Timeout) * time.Second); err != nil {
+		log.Error().Err(err).Msg("levant/stop: allocations of job failed to stop")
+		return false
+	}
+	return true
+}
+
+// waitForTerminalAllocs waits for every allocation of the job to reach a
+// terminal client status, which allows tasks time to drain according to their
+// shutdown delay and kill timeout. An error is returned if this does not
+// happen before the timeout is reached.
+func (l *levantDeployment) waitForTerminalAllocs(timeout time.Duration) error {
+
+	log.Info().Msgf("levant/stop: waiting up to %v for allocations of job to stop", timeout)
+
+	deadline := time.After(timeout)
+
+	q := nomadHelper.GenerateBlockingQueryOptions(l.config.Template.Job.Namespace)
+	q.WaitTime = 5 * time.Second
+
+	for {
+		select {
+		case <-deadline:
+			return fmt.Errorf("timeout reached waiting for allocations of job to stop")
+		default:
+		}
+
+		allocs, meta, err := l.nomad.Jobs().Allocations(*l.config.Template.Job.ID, false, q)
+		if err != nil {
+			return err
+		}
+
+		if meta.LastIndex <= q.WaitIndex {
+			continue
+		}
+		q.WaitIndex = meta.LastIndex
+
+		remaining := nonTerminalAllocs(allocs)
+		if len(remaining) == 0 {
+			log.Info().Msg("levant/stop: all allocations of job have stopped")
+			return nil
+		}
+
+		for _, alloc := range remaining {
+			log.Debug().Msgf("levant/stop: allocation %s has client status %s", alloc.ID, alloc.ClientStatus)
+		}
+		log.Info().Msgf("levant/stop: %v allocations of job are still stopping", len(remaining))
+	}
+}
+
+// nonTerminalAllocs returns the allocations which have not yet reached a
+// terminal client status.
+func nonTerminalAllocs(allocs []*nomad.AllocationListStub) []*nomad.AllocationListStub {
+
+	var out []*nomad.AllocationListStub
+	for _, alloc := range allocs {
+		switch alloc.ClientStatus {
+		case nomad.AllocClientStatusComplete, nomad.AllocClientStatusFailed, nomad.AllocClientStatusLost:
+		default:
+			out = append(out, alloc)
+		}
+	}
+	return out
+}
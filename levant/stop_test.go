@@ -0,0 +1,31 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"testing"
+
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStop_nonTerminalAllocs(t *testing.T) {
+
+	allocs := []*nomad.AllocationListStub{
+		{ID: "a", ClientStatus: nomad.AllocClientStatusComplete},
+		{ID: "b", ClientStatus: nomad.AllocClientStatusRunning},
+		{ID: "c", ClientStatus: nomad.AllocClientStatusFailed},
+		{ID: "d", ClientStatus: nomad.AllocClientStatusPending},
+		{ID: "e", ClientStatus: nomad.AllocClientStatusLost},
+		{ID: "f", ClientStatus: nomad.AllocClientStatusUnknown},
+	}
+
+	out := nonTerminalAllocs(allocs)
+	require.Len(t, out, 3)
+	require.Equal(t, "b", out[0].ID)
+	require.Equal(t, "d", out[1].ID)
+	require.Equal(t, "f", out[2].ID)
+
+	require.Empty(t, nonTerminalAllocs(allocs[:1]))
+}
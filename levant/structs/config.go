@@ -26,6 +26,11 @@ const (
 // DeployConfig is the main struct used to configure and run a Levant deployment on
 // a given target job.
 type DeployConfig struct {
+	// CapacityCheck enables a preflight check which runs a Nomad plan before
+	// registering the job, aborting the deployment if the cluster does not
+	// have the capacity to place the job.
+	CapacityCheck bool
+
 	// Canary enables canary autopromote and is the value in seconds to wait
 	// until attempting to perform autopromote.
 	Canary int
@@ -45,6 +50,24 @@ type DeployConfig struct {
 	// EnvVault is a boolean flag that can be used to enable reading the VAULT_TOKEN
 	// from the enviromment.
 	EnvVault bool
+
+	// StrictPlacement is a boolean flag that causes any failed task group
+	// placement within the job evaluation to be treated as a deployment failure.
+	StrictPlacement bool
+
+	// WaitConsulHealthy enables a final deployment check which waits for every
+	// service defined within the job to be registered and passing its health
+	// checks, in either Consul or Nomad depending on the service provider.
+	WaitConsulHealthy bool
+
+	// ConsulHealthyTimeout is the time in seconds to wait for the job services
+	// to become healthy when WaitConsulHealthy is enabled.
+	ConsulHealthyTimeout int
+
+	// BlockedEvalTimeout is the time in seconds to wait for a blocked evaluation
+	// to be resolved when StrictPlacement is enabled. A value of zero means the
+	// deployment fails as soon as a placement failure is detected.
+	BlockedEvalTimeout int
 }
 
 // ClientConfig is the config struct which houses all the information needed to connect
@@ -80,9 +103,62 @@ type TemplateConfig struct {
 	// before being deployed to the cluster.
 	TemplateFile string
 
+	// TemplatePaths are directories, or glob patterns, from which partial
+	// templates are loaded and made available to the templateFile.
+	TemplatePaths []string
+
 	// VariableFiles contains the variables which will be substituted into the
 	// templateFile before deployment.
 	VariableFiles []string
+
+	// NoDefaultVariableFile disables loading a levant.(yaml|yml|json|tf)
+	// variable file from the current directory when no VariableFiles are
+	// passed, so that rendering does not depend on where it is run from.
+	NoDefaultVariableFile bool
+
+	// ListMergeStrategy controls how lists are merged when the same variable is
+	// declared by multiple variable files. Supported values are "replace" and
+	// "append", with an empty value defaulting to replace.
+	ListMergeStrategy string
+
+	// VariableSchemaFile declares the variables the template expects, which
+	// are validated before the template is rendered.
+	VariableSchemaFile string
+
+	// SensitivePatterns are regular expressions, in addition to the default,
+	// used to identify sensitive variable names whose values are masked
+	// within log output.
+	SensitivePatterns []string
+
+	// StrictVariables causes rendering to fail when the template references a
+	// variable which has not been set, rather than rendering an empty value.
+	StrictVariables bool
+
+	// JobFormat is the format of the rendered job specification, either "hcl"
+	// or "json" for the Nomad API JSON format. When empty, the format is
+	// detected from the rendered output.
+	JobFormat string
+
+	// Offline renders the template without contacting Consul, Nomad, Vault or
+	// HTTP servers. Consul KV lookups are served from the ConsulFixtureFile
+	// when set, while all other external lookups fail.
+	Offline bool
+
+	// ConsulFixtureFile is a YAML or JSON file holding the Consul KV keys and
+	// values used in place of Consul when rendering offline.
+	ConsulFixtureFile string
+
+	// Lock records the result of every external lookup made while rendering
+	// to the LockFile.
+	Lock bool
+
+	// Locked replays the external lookups recorded in the LockFile rather
+	// than using the current upstream values, reporting any which differ.
+	Locked bool
+
+	// LockFile is the path of the lockfile written by Lock and read by
+	// Locked.
+	LockFile string
 }
 
 // ScaleConfig contains all the scaling specific configuration options.
@@ -109,3 +185,17 @@ type ScaleConfig struct {
 	// TaskGroup is the Nomad job taskgroup which has been selected for scaling.
 	TaskGroup string
 }
+
+// StopConfig contains all the stop specific configuration options.
+type StopConfig struct {
+	// Global is used to stop a multiregion job in all of its regions.
+	Global bool
+
+	// Purge is used to remove the job from Nomad immediately, rather than
+	// leaving it to be garbage collected.
+	Purge bool
+
+	// Timeout is the time in seconds to wait for all allocations of the job to
+	// reach a terminal client status.
+	Timeout int
+}
@@ -0,0 +1,159 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/levant/client"
+	"github.com/hashicorp/levant/levant/structs"
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/rs/zerolog/log"
+)
+
+// TriggerWatch provides the main entry point into watching an existing Nomad
+// deployment. The target is either the ID of a job, in which case its latest
+// deployment is watched, or the ID or ID prefix of a deployment.
+func TriggerWatch(config *DeployConfig, target string, nomadClient *nomad.Client) bool {
+
+	levantDep, dep, err := newWatchedDeployment(config, target, nomadClient)
+	if err != nil {
+		log.Error().Err(err).Msg("levant/watch: unable to setup Levant watch")
+		return false
+	}
+
+	if success := levantDep.watch(dep); !success {
+		log.Error().Msg("levant/watch: job deployment failed")
+		return false
+	}
+
+	log.Info().Msg("levant/watch: job deployment successful")
+	return true
+}
+
+// newWatchedDeployment finds the deployment of the target and sets up the
+// Levant deployment object used to act upon it.
+func newWatchedDeployment(config *DeployConfig, target string, nomadClient *nomad.Client) (*levantDeployment, *nomad.Deployment, error) {
+
+	var err error
+
+	if nomadClient == nil {
+		nomadClient, err = client.NewNomadClient(config.Client.Addr)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	dep, err := findDeployment(nomadClient, target, config.Client.AllowStale)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The job is not rendered when acting upon an existing deployment, so only
+	// the fields required to track the deployment are populated.
+	config.Template = &structs.TemplateConfig{
+		Job: &nomad.Job{
+			ID:        &dep.JobID,
+			Name:      &dep.JobID,
+			Namespace: &dep.Namespace,
+		},
+	}
+
+	levantDep, err := newLevantDeployment(config, nomadClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	return levantDep, dep, nil
+}
+
+// watch attaches to a deployment which has already been triggered and
+// monitors it in the same manner as a deployment started by Levant.
+func (l *levantDeployment) watch(dep *nomad.Deployment) (success bool) {
+
+	log.Info().Msgf("levant/watch: beginning deployment watcher for deployment %s of job version %v with status %s",
+		dep.ID, dep.JobVersion, dep.Status)
+
+	// Canary auto-promote is only possible when the deployment still has
+	// canaries waiting to be promoted.
+	if l.config.Deploy.Canary > 0 && !canariesAwaitingPromotion(dep) {
+		log.Info().Msgf("levant/watch: deployment %s has no canaries awaiting promotion, canary auto-promote will not run",
+			dep.ID)
+		l.config.Deploy.Canary = 0
+	}
+
+	if success = l.deploymentWatcher(dep.ID); success {
+		return
+	}
+
+	latest, _, err := l.nomad.Deployments().Info(dep.ID, nil)
+	if err != nil {
+		log.Error().Err(err).Msgf("levant/watch: unable to query deployment %s for auto-revert check", dep.ID)
+		return
+	}
+
+	// Match the deploy behaviour of only checking auto-revert for jobs which
+	// are not using canaries.
+	if !deploymentHasCanaries(latest) {
+		l.checkAutoRevert(latest)
+	}
+	return
+}
+
+// findDeployment returns the latest deployment of the job with the passed ID,
+// falling back to the deployment with the passed ID or unique ID prefix.
+func findDeployment(c *nomad.Client, target string, allowStale bool) (*nomad.Deployment, error) {
+
+	q := &nomad.QueryOptions{AllowStale: allowStale}
+
+	dep, _, err := c.Jobs().LatestDeployment(target, q)
+	if err != nil && !strings.Contains(err.Error(), "404") {
+		return nil, err
+	}
+	if dep != nil {
+		return dep, nil
+	}
+
+	q.Prefix = target
+	deps, _, err := c.Deployments().List(q)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(deps) {
+	case 0:
+		return nil, fmt.Errorf("no deployment found for job or deployment %s", target)
+	case 1:
+		return deps[0], nil
+	default:
+		return nil, fmt.Errorf("deployment prefix %s matched %v deployments", target, len(deps))
+	}
+}
+
+// deploymentHasCanaries returns whether any task group within the deployment
+// is configured to use canaries.
+func deploymentHasCanaries(dep *nomad.Deployment) bool {
+	for _, state := range dep.TaskGroups {
+		if state.DesiredCanaries > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// canariesAwaitingPromotion returns whether the deployment is running and has
+// task groups with canaries which have not yet been promoted.
+func canariesAwaitingPromotion(dep *nomad.Deployment) bool {
+
+	if dep.Status != jobStatusRunning {
+		return false
+	}
+
+	for _, state := range dep.TaskGroups {
+		if state.DesiredCanaries > 0 && !state.Promoted {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package levant
+
+import (
+	"testing"
+
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch_canaryChecks(t *testing.T) {
+
+	cases := []struct {
+		dep              *nomad.Deployment
+		expectedCanaries bool
+		expectedAwaiting bool
+	}{
+		{
+			&nomad.Deployment{
+				Status: "running",
+				TaskGroups: map[string]*nomad.DeploymentState{
+					"web": {DesiredTotal: 3},
+				},
+			},
+			false,
+			false,
+		},
+		{
+			&nomad.Deployment{
+				Status: "running",
+				TaskGroups: map[string]*nomad.DeploymentState{
+					"web":   {DesiredTotal: 3},
+					"cache": {DesiredTotal: 2, DesiredCanaries: 1},
+				},
+			},
+			true,
+			true,
+		},
+		{
+			&nomad.Deployment{
+				Status: "running",
+				TaskGroups: map[string]*nomad.DeploymentState{
+					"web": {DesiredTotal: 3, DesiredCanaries: 1, Promoted: true},
+				},
+			},
+			true,
+			false,
+		},
+		{
+			&nomad.Deployment{
+				Status: "failed",
+				TaskGroups: map[string]*nomad.DeploymentState{
+					"web": {DesiredTotal: 3, DesiredCanaries: 1},
+				},
+			},
+			true,
+			false,
+		},
+	}
+
+	for i, tc := range cases {
+		require.Equal(t, tc.expectedCanaries, deploymentHasCanaries(tc.dep), "case %v", i)
+		require.Equal(t, tc.expectedAwaiting, canariesAwaitingPromotion(tc.dep), "case %v", i)
+	}
+}
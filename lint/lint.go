@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	nomad "github.com/hashicorp/nomad/api"
+)
+
+// Severity is the severity of a lint finding.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+
+	// SeverityOff disables a rule.
+	SeverityOff Severity = "off"
+)
+
+// rank orders the severities so that findings can be compared against a
+// minimum severity.
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// AtLeast returns whether the severity is at least as severe as the passed
+// severity.
+func (s Severity) AtLeast(min Severity) bool {
+	return s.rank() >= min.rank() && s.rank() > 0
+}
+
+// ParseSeverity parses the passed severity name.
+func ParseSeverity(s string) (Severity, error) {
+	switch sev := Severity(strings.ToLower(s)); sev {
+	case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		return sev, nil
+	default:
+		return "", fmt.Errorf("severity %q not supported, must be one of error, warning, info or off", s)
+	}
+}
+
+// Finding is a single problem found within a job.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+
+	// Group and Task identify the part of the job the finding relates to, and
+	// are empty for findings which relate to the whole job.
+	Group string `json:"group,omitempty"`
+	Task  string `json:"task,omitempty"`
+
+	// File and Line locate the finding within the job template. Line is zero
+	// if the location could not be determined.
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// Config configures a lint run.
+type Config struct {
+	// Severities overrides the default severity of rules by rule name.
+	Severities map[string]Severity
+
+	// TemplateFile is the name of the job template, which is reported as the
+	// file of each finding.
+	TemplateFile string
+
+	// Template is the source of the job template, which is used to locate
+	// findings within the template.
+	Template []byte
+
+	// Rendered is the rendered job template, which is used to locate findings
+	// and to find suppression comments.
+	Rendered []byte
+}
+
+// Lint runs all enabled rules against the passed job, returning the findings
+// which have not been suppressed ordered by their location within the
+// template.
+func Lint(job *nomad.Job, config *Config) ([]*Finding, error) {
+
+	for name := range config.Severities {
+		if findRule(name) == nil {
+			return nil, fmt.Errorf("lint rule %q not found", name)
+		}
+	}
+
+	src := newSource(config.Rendered, config.Template)
+
+	var findings []*Finding
+
+	for _, rule := range Rules {
+		severity := rule.Severity
+		if sev, ok := config.Severities[rule.Name]; ok {
+			severity = sev
+		}
+		if severity == SeverityOff {
+			continue
+		}
+
+		for _, f := range rule.Check(job) {
+			if src.suppressed(rule.Name, f.Group, f.Task) {
+				continue
+			}
+			f.Rule = rule.Name
+			f.Severity = severity
+			f.File = config.TemplateFile
+			f.Line = src.line(f.Group, f.Task)
+			findings = append(findings, f)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+}
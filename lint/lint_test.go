@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package lint
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/hashicorp/levant/template"
+)
+
+func TestLint_Lint(t *testing.T) {
+
+	templateFile := "test-fixtures/lint.nomad"
+	fVars := map[string]interface{}{"job_name": "example"}
+
+	tpl, job, err := template.RenderWithConfig(&structs.TemplateConfig{TemplateFile: templateFile},
+		&structs.ClientConfig{}, &fVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := os.ReadFile(templateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{TemplateFile: templateFile, Template: src, Rendered: tpl.Bytes()}
+
+	findings, err := Lint(job, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []string
+	for _, f := range findings {
+		actual = append(actual, fmt.Sprintf("%s:%v:%s:%s", f.File, f.Line, f.Severity, f.Rule))
+	}
+
+	expected := []string{
+		"test-fixtures/lint.nomad:4:error:missing-type",
+		"test-fixtures/lint.nomad:7:warning:canary-without-auto-promote",
+		"test-fixtures/lint.nomad:7:warning:service-without-check",
+		"test-fixtures/lint.nomad:17:warning:missing-resources",
+		"test-fixtures/lint.nomad:17:warning:latest-image-tag",
+		"test-fixtures/lint.nomad:33:warning:service-without-update",
+	}
+
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Fatalf("expected findings:\n%v\nbut got:\n%v", expected, actual)
+	}
+
+	// Rules can be disabled and their severity changed.
+	config.Severities = map[string]Severity{"missing-type": SeverityOff, "latest-image-tag": SeverityError}
+	if findings, err = Lint(job, config); err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 5 || findings[0].Rule != "canary-without-auto-promote" || findings[3].Severity != SeverityError {
+		t.Fatalf("unexpected findings with severity overrides: %v", findings)
+	}
+
+	config.Severities = map[string]Severity{"unknown": SeverityOff}
+	if _, err = Lint(job, config); err == nil {
+		t.Fatal("expected error for unknown rule")
+	}
+}
+
+func TestLint_unpinnedImage(t *testing.T) {
+
+	cases := map[string]bool{
+		"redis":                             true,
+		"redis:latest":                      true,
+		"redis:7":                           false,
+		"registry.example.com:5000/redis":   true,
+		"registry.example.com:5000/redis:7": false,
+		"redis@sha256:abcdef":               false,
+	}
+
+	for image, expected := range cases {
+		if actual := unpinnedImage(image); actual != expected {
+			t.Fatalf("expected unpinnedImage(%q) to be %v but got %v", image, expected, actual)
+		}
+	}
+}
+
+func TestLint_parseIgnoreComment(t *testing.T) {
+
+	cases := map[string][]string{
+		"# levant:ignore latest-image-tag":    {"latest-image-tag"},
+		"// levant:ignore a, b c":             {"a", "b", "c"},
+		"/* levant:ignore all */":             {"all"},
+		"# not a directive levant:ignore all": nil,
+	}
+
+	for comment, expected := range cases {
+		if actual := parseIgnoreComment(comment); fmt.Sprint(actual) != fmt.Sprint(expected) {
+			t.Fatalf("expected %v for %q but got %v", expected, comment, actual)
+		}
+	}
+}
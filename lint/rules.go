@@ -0,0 +1,224 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	nomad "github.com/hashicorp/nomad/api"
+)
+
+// Rule is a single lint check run against a job.
+type Rule struct {
+	// Name identifies the rule within severity overrides and suppression
+	// comments.
+	Name string
+
+	// Description describes what the rule checks.
+	Description string
+
+	// Severity is the default severity of findings from the rule.
+	Severity Severity
+
+	// Check returns the problems the rule finds within the job. The rule,
+	// severity and location of the findings are set by Lint.
+	Check func(job *nomad.Job) []*Finding
+}
+
+// Rules are the built-in lint rules, in the order they are run.
+var Rules = []*Rule{
+	{
+		Name:        "missing-type",
+		Description: "The job type is not set, which Levant requires to track the deployment.",
+		Severity:    SeverityError,
+		Check:       checkMissingType,
+	},
+	{
+		Name:        "service-without-update",
+		Description: "A service job group has no update block, so allocations are replaced without health checking.",
+		Severity:    SeverityWarning,
+		Check:       checkServiceWithoutUpdate,
+	},
+	{
+		Name:        "canary-without-auto-promote",
+		Description: "A group uses canaries without auto_promote, so deployments wait to be promoted manually.",
+		Severity:    SeverityWarning,
+		Check:       checkCanaryWithoutAutoPromote,
+	},
+	{
+		Name:        "missing-resources",
+		Description: "A task has no resources block, so it is given the default CPU and memory.",
+		Severity:    SeverityWarning,
+		Check:       checkMissingResources,
+	},
+	{
+		Name:        "latest-image-tag",
+		Description: "A task image uses the latest tag or no tag, so the deployed version is not pinned.",
+		Severity:    SeverityWarning,
+		Check:       checkLatestImageTag,
+	},
+	{
+		Name:        "service-without-check",
+		Description: "A service has no health check, so its health is not taken into account by deployments.",
+		Severity:    SeverityWarning,
+		Check:       checkServiceWithoutCheck,
+	},
+}
+
+// findRule returns the rule with the passed name, or nil if it does not exist.
+func findRule(name string) *Rule {
+	for _, r := range Rules {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// isServiceJob returns whether the job is a service job, which is the default
+// job type.
+func isServiceJob(job *nomad.Job) bool {
+	return job.Type == nil || *job.Type == nomad.JobTypeService
+}
+
+func checkMissingType(job *nomad.Job) []*Finding {
+	if job.Type != nil {
+		return nil
+	}
+	return []*Finding{{
+		Message: fmt.Sprintf("job type is not set; should be set to %s, %s or %s",
+			nomad.JobTypeBatch, nomad.JobTypeSystem, nomad.JobTypeService),
+	}}
+}
+
+func checkServiceWithoutUpdate(job *nomad.Job) []*Finding {
+	if !isServiceJob(job) || job.Update != nil {
+		return nil
+	}
+
+	var findings []*Finding
+	for _, tg := range job.TaskGroups {
+		if tg.Update == nil {
+			findings = append(findings, &Finding{
+				Group:   *tg.Name,
+				Message: fmt.Sprintf("service group %s has no update block", *tg.Name),
+			})
+		}
+	}
+	return findings
+}
+
+func checkCanaryWithoutAutoPromote(job *nomad.Job) []*Finding {
+
+	var findings []*Finding
+
+	for _, tg := range job.TaskGroups {
+		var canary int
+		var autoPromote bool
+
+		// Group update blocks are merged with the job update block.
+		for _, u := range []*nomad.UpdateStrategy{job.Update, tg.Update} {
+			if u == nil {
+				continue
+			}
+			if u.Canary != nil {
+				canary = *u.Canary
+			}
+			if u.AutoPromote != nil {
+				autoPromote = *u.AutoPromote
+			}
+		}
+
+		if canary > 0 && !autoPromote {
+			findings = append(findings, &Finding{
+				Group:   *tg.Name,
+				Message: fmt.Sprintf("group %s uses %v canaries without auto_promote", *tg.Name, canary),
+			})
+		}
+	}
+	return findings
+}
+
+func checkMissingResources(job *nomad.Job) []*Finding {
+
+	var findings []*Finding
+
+	for _, tg := range job.TaskGroups {
+		for _, task := range tg.Tasks {
+			if task.Resources == nil {
+				findings = append(findings, &Finding{
+					Group:   *tg.Name,
+					Task:    task.Name,
+					Message: fmt.Sprintf("task %s has no resources block", task.Name),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+func checkLatestImageTag(job *nomad.Job) []*Finding {
+
+	var findings []*Finding
+
+	for _, tg := range job.TaskGroups {
+		for _, task := range tg.Tasks {
+			image, ok := task.Config["image"].(string)
+			if !ok || image == "" || !unpinnedImage(image) {
+				continue
+			}
+			findings = append(findings, &Finding{
+				Group:   *tg.Name,
+				Task:    task.Name,
+				Message: fmt.Sprintf("task %s image %s is not pinned to a version", task.Name, image),
+			})
+		}
+	}
+	return findings
+}
+
+// unpinnedImage returns whether the image reference uses the latest tag, or
+// has neither a tag nor a digest.
+func unpinnedImage(image string) bool {
+
+	if strings.Contains(image, "@") {
+		return false
+	}
+
+	// The tag follows the last colon, as long as it is not part of the
+	// registry host and port.
+	name := image[strings.LastIndex(image, "/")+1:]
+	i := strings.LastIndex(name, ":")
+	if i < 0 {
+		return true
+	}
+	return name[i+1:] == "latest"
+}
+
+func checkServiceWithoutCheck(job *nomad.Job) []*Finding {
+
+	var findings []*Finding
+
+	check := func(services []*nomad.Service, group, task string) {
+		for _, s := range services {
+			if len(s.Checks) > 0 {
+				continue
+			}
+			msg := fmt.Sprintf("service %s has no health check", s.Name)
+			if s.Name == "" {
+				msg = "service has no health check"
+			}
+			findings = append(findings, &Finding{Group: group, Task: task, Message: msg})
+		}
+	}
+
+	for _, tg := range job.TaskGroups {
+		check(tg.Services, *tg.Name, "")
+		for _, task := range tg.Tasks {
+			check(task.Services, *tg.Name, task.Name)
+		}
+	}
+	return findings
+}
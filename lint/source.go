@@ -0,0 +1,220 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// ignoreDirective is the prefix of comments which suppress rules. The
+// directive is followed by the names of the rules to suppress, separated by
+// commas or spaces, or all to suppress every rule.
+const ignoreDirective = "levant:ignore"
+
+// block is a job, group or task block within the rendered job.
+type block struct {
+	scope string
+	rng   hcl.Range
+	depth int
+}
+
+// source locates findings within the job template and holds the rules
+// suppressed within each part of the job.
+type source struct {
+	blocks  []*block
+	lines   map[string]int
+	ignored map[string]map[string]bool
+}
+
+// newSource parses the rendered job to find the job, group and task blocks
+// along with any suppression comments. Rendered jobs which are not HCL, such
+// as API JSON jobs, cannot include suppression comments and their findings
+// are not located.
+func newSource(rendered, template []byte) *source {
+
+	s := &source{
+		lines:   make(map[string]int),
+		ignored: make(map[string]map[string]bool),
+	}
+
+	file, diags := hclsyntax.ParseConfig(rendered, "", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return s
+	}
+
+	if body, ok := file.Body.(*hclsyntax.Body); ok {
+		s.walk(body, "", 0)
+	}
+	s.locate(template)
+
+	tokens, _ := hclsyntax.LexConfig(rendered, "", hcl.Pos{Line: 1, Column: 1})
+	for _, tok := range tokens {
+		if tok.Type != hclsyntax.TokenComment {
+			continue
+		}
+		rules := parseIgnoreComment(string(tok.Bytes))
+		if len(rules) == 0 {
+			continue
+		}
+
+		scope := s.commentScope(tok.Range.Start.Line)
+		if s.ignored[scope] == nil {
+			s.ignored[scope] = make(map[string]bool)
+		}
+		for _, r := range rules {
+			s.ignored[scope][r] = true
+		}
+	}
+	return s
+}
+
+// walk records the job, group and task blocks within the body. The scope of
+// the job block is empty, groups are identified by their name and tasks by
+// their group and task name.
+func (s *source) walk(body *hclsyntax.Body, parent string, depth int) {
+
+	for _, b := range body.Blocks {
+		var scope string
+
+		switch {
+		case depth == 0 && b.Type == "job":
+			scope = ""
+		case depth == 1 && b.Type == "group" && len(b.Labels) == 1:
+			scope = b.Labels[0]
+		case depth == 2 && b.Type == "task" && len(b.Labels) == 1:
+			scope = parent + "/" + b.Labels[0]
+		default:
+			continue
+		}
+
+		s.blocks = append(s.blocks, &block{scope: scope, rng: b.Range(), depth: depth})
+		s.walk(b.Body, scope, depth+1)
+	}
+}
+
+// locate finds the line of each block header within the job template. The
+// rendered line is used when the template is not available, and blocks whose
+// header is templated are located at their parent.
+func (s *source) locate(template []byte) {
+
+	if len(template) == 0 {
+		for _, b := range s.blocks {
+			s.lines[b.scope] = b.rng.Start.Line
+		}
+		return
+	}
+
+	lines := strings.Split(string(template), "\n")
+
+	find := func(pattern string, from int) int {
+		re := regexp.MustCompile(pattern)
+		for i := from; i < len(lines); i++ {
+			if re.MatchString(lines[i]) {
+				return i + 1
+			}
+		}
+		return 0
+	}
+
+	for _, b := range s.blocks {
+		switch b.depth {
+		case 0:
+			s.lines[b.scope] = find(`^\s*job\s+"`, 0)
+		default:
+			parent, name := "", b.scope
+			if i := strings.LastIndex(b.scope, "/"); i >= 0 {
+				parent, name = b.scope[:i], b.scope[i+1:]
+			}
+			blockType := "group"
+			if b.depth == 2 {
+				blockType = "task"
+			}
+
+			line := s.lines[parent]
+			if l := find(fmt.Sprintf(`^\s*%s\s+"%s"`, blockType, regexp.QuoteMeta(name)), line); l > 0 {
+				line = l
+			}
+			s.lines[b.scope] = line
+		}
+	}
+}
+
+// commentScope returns the scope a suppression comment on the passed line
+// applies to. Comments on the line before a block header apply to that
+// block, and other comments apply to the innermost block they are within.
+func (s *source) commentScope(line int) string {
+
+	scope, depth := "", -1
+
+	for _, b := range s.blocks {
+		if b.rng.Start.Line == line+1 {
+			return b.scope
+		}
+		if b.rng.Start.Line <= line && b.rng.End.Line >= line && b.depth > depth {
+			scope, depth = b.scope, b.depth
+		}
+	}
+	return scope
+}
+
+// suppressed returns whether the rule is suppressed for the part of the job
+// identified by the group and task, including by a suppression within an
+// enclosing block.
+func (s *source) suppressed(rule, group, task string) bool {
+
+	scopes := []string{""}
+	if group != "" {
+		scopes = append(scopes, group)
+		if task != "" {
+			scopes = append(scopes, group+"/"+task)
+		}
+	}
+
+	for _, scope := range scopes {
+		if s.ignored[scope][rule] || s.ignored[scope]["all"] {
+			return true
+		}
+	}
+	return false
+}
+
+// line returns the template line of the part of the job identified by the
+// group and task, falling back to the enclosing block.
+func (s *source) line(group, task string) int {
+	if task != "" {
+		if l, ok := s.lines[group+"/"+task]; ok {
+			return l
+		}
+	}
+	if group != "" {
+		if l, ok := s.lines[group]; ok {
+			return l
+		}
+	}
+	return s.lines[""]
+}
+
+// parseIgnoreComment returns the rules named by a suppression comment, or nil
+// if the comment is not a suppression comment.
+func parseIgnoreComment(comment string) []string {
+
+	text := strings.TrimSpace(comment)
+	for _, prefix := range []string{"#", "//", "/*"} {
+		text = strings.TrimPrefix(text, prefix)
+	}
+	text = strings.TrimSpace(strings.TrimSuffix(text, "*/"))
+
+	if !strings.HasPrefix(text, ignoreDirective) {
+		return nil
+	}
+
+	return strings.FieldsFunc(strings.TrimPrefix(text, ignoreDirective), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/hashicorp/levant/helper"
 	isatty "github.com/mattn/go-isatty"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -66,6 +67,9 @@ func setLogFormat(format string) error {
 		logWriter = os.Stderr
 	}
 
+	// Ensure any values marked as sensitive are masked in all log output.
+	logWriter = helper.NewRedactWriter(logWriter)
+
 	switch format {
 	case "HUMAN":
 		w := zerolog.ConsoleWriter{
@@ -0,0 +1,280 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/rs/zerolog/log"
+)
+
+// The names of the data sources included with Levant, along with the type of
+// the values they return.
+const (
+	// DataSourceConsul reads Consul KV keys, returning string values.
+	DataSourceConsul = "consul"
+
+	// DataSourceEnv reads environment variables, returning string values.
+	DataSourceEnv = "env"
+
+	// DataSourceFile reads local files, returning the contents as a string.
+	DataSourceFile = "file"
+
+	// DataSourceHTTP fetches HTTP(S) URLs, returning HTTPContent values.
+	DataSourceHTTP = "http"
+
+	// DataSourceNomadService reads Nomad service registrations by service
+	// name, returning []*nomad.ServiceRegistration values.
+	DataSourceNomadService = "nomadService"
+
+	// DataSourceNomadVar reads Nomad Variables by path, returning the items of
+	// the variable as map[string]string values.
+	DataSourceNomadVar = "nomadVar"
+
+	// DataSourceTime returns the current time as a time.Time value.
+	DataSourceTime = "time"
+
+	// DataSourceVault reads Vault secrets by path, returning the secret data
+	// as map[string]interface{} values.
+	DataSourceVault = "vault"
+)
+
+// DataSource is a system external to the template which template functions
+// and variable sources read values from, such as Consul KV or the
+// environment.
+type DataSource interface {
+	// Get returns the value stored under the key. The returned bool is false
+	// if the key does not exist.
+	Get(key string) (interface{}, bool, error)
+}
+
+// DataSourceLister is implemented by data sources which can also return all
+// values stored under a key prefix, keyed by their full key.
+type DataSourceLister interface {
+	DataSource
+	List(prefix string) (map[string]interface{}, error)
+}
+
+// DataSourceFactory creates a data source using the client configuration of
+// a render. Factories are only called on the first lookup against the source
+// during a render, so clients are not created for unused sources.
+type DataSourceFactory func(config *structs.ClientConfig) (DataSource, error)
+
+// FuncFactory builds a template function, which may read from the data
+// sources of the render it is used in. The returned value must be a function
+// which meets the requirements of text/template.
+type FuncFactory func(sources *DataSources) interface{}
+
+// registry holds the data sources and template functions available to all
+// renders.
+var registry = struct {
+	sync.RWMutex
+	sources map[string]DataSourceFactory
+	funcs   map[string]FuncFactory
+}{
+	sources: map[string]DataSourceFactory{
+		DataSourceConsul:       newConsulDataSource,
+		DataSourceEnv:          newEnvDataSource,
+		DataSourceFile:         newFileDataSource,
+		DataSourceHTTP:         newHTTPDataSource,
+		DataSourceNomadService: newNomadServiceDataSource,
+		DataSourceNomadVar:     newNomadVarDataSource,
+		DataSourceTime:         newTimeDataSource,
+		DataSourceVault:        newVaultDataSource,
+	},
+	funcs: map[string]FuncFactory{},
+}
+
+// RegisterDataSource makes a data source available to all renders under the
+// passed name, replacing any existing source of the same name including those
+// included with Levant.
+func RegisterDataSource(name string, factory DataSourceFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.sources[name] = factory
+}
+
+// RegisterFunc makes a template function available to all renders under the
+// passed name. Registered functions take precedence over both the Levant and
+// Sprig functions of the same name.
+func RegisterFunc(name string, factory FuncFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.funcs[name] = factory
+}
+
+// registeredFuncs builds the registered template functions using the passed
+// data sources.
+func registeredFuncs(sources *DataSources) map[string]interface{} {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	funcs := make(map[string]interface{}, len(registry.funcs))
+	for name, factory := range registry.funcs {
+		funcs[name] = factory(sources)
+	}
+	return funcs
+}
+
+// DataSources holds the data sources used during a single render. Sources
+// are created from the registry when first used, unless the render is
+// offline in which case only the sources passed when rendering are
+// available.
+type DataSources struct {
+	config  *structs.ClientConfig
+	offline bool
+
+	// lookups records or replays the lookups made against the sources when
+	// rendering with a lockfile.
+	lookups *lookupLock
+
+	sourcesLock sync.Mutex
+	sources     map[string]DataSource
+}
+
+// newDataSources returns the data sources for a render. The passed sources
+// take precedence over those in the registry.
+func newDataSources(config *structs.ClientConfig, sources map[string]DataSource, offline bool) *DataSources {
+
+	if config == nil {
+		config = &structs.ClientConfig{}
+	}
+
+	d := &DataSources{
+		config:  config,
+		offline: offline,
+		sources: make(map[string]DataSource, len(sources)),
+	}
+	for name, source := range sources {
+		d.sources[name] = source
+	}
+	return d
+}
+
+// Source returns the named data source, creating it if this is the first
+// time it has been used within the render.
+func (d *DataSources) Source(name string) (DataSource, error) {
+
+	d.sourcesLock.Lock()
+	defer d.sourcesLock.Unlock()
+
+	if source, ok := d.sources[name]; ok {
+		return source, nil
+	}
+
+	if d.offline {
+		return nil, fmt.Errorf("data source %s is not available when rendering offline", name)
+	}
+
+	registry.RLock()
+	factory, ok := registry.sources[name]
+	registry.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("data source %s is not registered", name)
+	}
+
+	source, err := factory(d.config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create %s data source: %v", name, err)
+	}
+
+	log.Debug().Msgf("template/datasource: created %s data source", name)
+	d.sources[name] = source
+
+	return source, nil
+}
+
+// Get returns the value stored under the key within the named data source.
+func (d *DataSources) Get(name, key string) (interface{}, bool, error) {
+	if d.lookups != nil {
+		return d.lookups.get(d, name, key)
+	}
+	return d.get(name, key)
+}
+
+func (d *DataSources) get(name, key string) (interface{}, bool, error) {
+	source, err := d.Source(name)
+	if err != nil {
+		return nil, false, err
+	}
+	return source.Get(key)
+}
+
+// List returns all values stored under the key prefix within the named data
+// source, which must implement DataSourceLister.
+func (d *DataSources) List(name, prefix string) (map[string]interface{}, error) {
+	if d.lookups != nil {
+		return d.lookups.list(d, name, prefix)
+	}
+	return d.list(name, prefix)
+}
+
+func (d *DataSources) list(name, prefix string) (map[string]interface{}, error) {
+
+	source, err := d.Source(name)
+	if err != nil {
+		return nil, err
+	}
+
+	lister, ok := source.(DataSourceLister)
+	if !ok {
+		return nil, fmt.Errorf("data source %s does not support listing keys", name)
+	}
+	return lister.List(prefix)
+}
+
+// getDataSourceValue returns the value stored under the key within the named
+// data source, checking it is of the type expected by the caller.
+func getDataSourceValue[T any](sources *DataSources, name, key string) (T, bool, error) {
+
+	var value T
+
+	v, ok, err := sources.Get(name, key)
+	if err != nil || !ok {
+		return value, ok, err
+	}
+
+	value, ok = v.(T)
+	if !ok {
+		return value, false, fmt.Errorf("data source %s returned %T for key %s, expected %T", name, v, key, value)
+	}
+	return value, true, nil
+}
+
+// MapDataSource is a DataSource which returns the values held in the map. It
+// is used to provide fixed values in place of external systems, such as when
+// testing templates.
+type MapDataSource map[string]interface{}
+
+// Get returns the value of the key from the map.
+func (m MapDataSource) Get(key string) (interface{}, bool, error) {
+	v, ok := m[key]
+	return v, ok, nil
+}
+
+// List returns all values within the map with keys starting with the prefix.
+func (m MapDataSource) List(prefix string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for k, v := range m {
+		if strings.HasPrefix(k, prefix) {
+			values[k] = v
+		}
+	}
+	return values, nil
+}
+
+// sortedKeys returns the keys of the map in lexical order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
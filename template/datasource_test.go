@@ -0,0 +1,143 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/levant/helper"
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataSource_RenderWithDataSources(t *testing.T) {
+
+	src := `job "[[ consulKey "app/name" ]]" {
+  type = "service"
+  meta {
+    region   = "[[ env "REGION" ]]"
+    config   = "[[ fileContents "app.conf" ]]"
+    image    = "[[ nomadVarOrDefault "nomad/jobs/app" "image" "redis:7" ]]"
+    password = "[[ (vaultSecret "secret/data/app").password ]]"
+    custom   = "[[ dataSourceTestFunc "key" ]]"
+  }
+  group "cache" {
+    task "redis" {
+      driver = "docker"
+    }
+  }
+}`
+
+	tmp := t.TempDir() + "/sources.nomad"
+	require.NoError(t, os.WriteFile(tmp, []byte(src), 0600))
+
+	RegisterDataSource("dataSourceTest", func(_ *structs.ClientConfig) (DataSource, error) {
+		return MapDataSource{"key": "registered"}, nil
+	})
+	RegisterFunc("dataSourceTestFunc", func(sources *DataSources) interface{} {
+		return func(key string) (string, error) {
+			v, _, err := getDataSourceValue[string](sources, "dataSourceTest", key)
+			return v, err
+		}
+	})
+
+	sources := map[string]DataSource{
+		DataSourceConsul:   MapDataSource{"app/name": "example"},
+		DataSourceEnv:      MapDataSource{"REGION": "eu-west-1"},
+		DataSourceFile:     MapDataSource{"app.conf": "debug"},
+		DataSourceNomadVar: MapDataSource{"nomad/jobs/app": map[string]string{"image": "redis:8"}},
+		DataSourceVault:    MapDataSource{"secret/data/app": map[string]interface{}{"password": "v4ult-s3cr3t"}},
+	}
+
+	fVars := make(map[string]interface{})
+	_, job, err := RenderWithDataSources(&structs.TemplateConfig{TemplateFile: tmp}, nil, sources, &fVars)
+	require.NoError(t, err)
+
+	require.Equal(t, "example", *job.ID)
+	require.Equal(t, map[string]string{
+		"region":   "eu-west-1",
+		"config":   "debug",
+		"image":    "redis:8",
+		"password": "v4ult-s3cr3t",
+		"custom":   "registered",
+	}, job.Meta)
+
+	// Vault secrets are masked in log output.
+	require.Equal(t, "value "+helper.RedactedValue, helper.Redact("value v4ult-s3cr3t"))
+
+	// A fake returning the wrong type of value is reported.
+	sources[DataSourceConsul] = MapDataSource{"app/name": 1}
+	_, _, err = RenderWithDataSources(&structs.TemplateConfig{TemplateFile: tmp}, nil, sources, &fVars)
+	require.ErrorContains(t, err, "data source consul returned int for key app/name, expected string")
+}
+
+func TestDataSource_Offline(t *testing.T) {
+
+	d := newDataSources(nil, map[string]DataSource{DataSourceEnv: MapDataSource{"A": "1"}}, true)
+
+	v, ok, err := d.Get(DataSourceEnv, "A")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "1", v)
+
+	_, _, err = d.Get(DataSourceNomadVar, "nomad/jobs/app")
+	require.EqualError(t, err, "data source nomadVar is not available when rendering offline")
+
+	d = newDataSources(nil, nil, false)
+	_, _, err = d.Get("unknown", "key")
+	require.EqualError(t, err, "data source unknown is not registered")
+}
+
+func TestDataSource_vault(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/app":
+			_, _ = w.Write([]byte(`{"data": {"data": {"password": "s3cr3t"}, "metadata": {"version": 1}}}`))
+		case "/v1/kv/app":
+			_, _ = w.Write([]byte(`{"data": {"password": "s3cr3t"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "")
+
+	_, err := newVaultDataSource(nil)
+	require.Error(t, err)
+
+	t.Setenv("VAULT_TOKEN", "root")
+	source, err := newVaultDataSource(nil)
+	require.NoError(t, err)
+
+	// Both KV version 1 and 2 secrets return the secret data.
+	for _, p := range []string{"secret/data/app", "kv/app"} {
+		v, ok, err := source.Get(p)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, map[string]interface{}{"password": "s3cr3t"}, v)
+	}
+
+	_, ok, err := source.Get("secret/data/missing")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	t.Setenv("VAULT_TOKEN", "other")
+	source, err = newVaultDataSource(nil)
+	require.NoError(t, err)
+
+	_, _, err = source.Get("secret/data/app")
+	require.ErrorContains(t, err, "permission denied")
+}
@@ -0,0 +1,282 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/levant/client"
+	"github.com/hashicorp/levant/levant/structs"
+	nomad "github.com/hashicorp/nomad/api"
+)
+
+const (
+	// httpDataSourceTimeout is the maximum time allowed for a request made by
+	// the HTTP and Vault data sources.
+	httpDataSourceTimeout = 30 * time.Second
+
+	// defaultVaultAddr is the Vault address used when VAULT_ADDR is not set,
+	// matching the Vault CLI.
+	defaultVaultAddr = "https://127.0.0.1:8200"
+
+	// timeDataSourceKey is the key used to read the current time from the
+	// time data source.
+	timeDataSourceKey = "now"
+)
+
+// HTTPContent is the value returned by the HTTP data source.
+type HTTPContent struct {
+	Body        []byte
+	ContentType string
+}
+
+// consulDataSource reads keys from the Consul KV store.
+type consulDataSource struct {
+	kv *consul.KV
+}
+
+func newConsulDataSource(config *structs.ClientConfig) (DataSource, error) {
+	c, err := client.NewConsulClient(config.ConsulAddr)
+	if err != nil {
+		return nil, err
+	}
+	return &consulDataSource{kv: c.KV()}, nil
+}
+
+func (c *consulDataSource) Get(key string) (interface{}, bool, error) {
+	pair, _, err := c.kv.Get(key, nil)
+	if err != nil || pair == nil {
+		return nil, false, err
+	}
+	return string(pair.Value), true, nil
+}
+
+func (c *consulDataSource) List(prefix string) (map[string]interface{}, error) {
+	pairs, _, err := c.kv.List(prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(pairs))
+	for _, pair := range pairs {
+		values[pair.Key] = string(pair.Value)
+	}
+	return values, nil
+}
+
+// envDataSource reads the environment variables of the Levant process.
+type envDataSource struct{}
+
+func newEnvDataSource(_ *structs.ClientConfig) (DataSource, error) {
+	return envDataSource{}, nil
+}
+
+func (envDataSource) Get(key string) (interface{}, bool, error) {
+	v, ok := os.LookupEnv(key)
+	return v, ok, nil
+}
+
+func (envDataSource) List(prefix string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, prefix) {
+			values[k] = v
+		}
+	}
+	return values, nil
+}
+
+// fileDataSource reads files from the local filesystem.
+type fileDataSource struct{}
+
+func newFileDataSource(_ *structs.ClientConfig) (DataSource, error) {
+	return fileDataSource{}, nil
+}
+
+func (fileDataSource) Get(key string) (interface{}, bool, error) {
+	contents, err := os.ReadFile(key)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return string(contents), true, nil
+}
+
+// httpDataSource fetches URLs over HTTP(S). A 404 response is treated as the
+// key not existing.
+type httpDataSource struct {
+	client *http.Client
+}
+
+func newHTTPDataSource(_ *structs.ClientConfig) (DataSource, error) {
+	return &httpDataSource{client: &http.Client{Timeout: httpDataSourceTimeout}}, nil
+}
+
+func (h *httpDataSource) Get(key string) (interface{}, bool, error) {
+
+	resp, err := h.client.Get(key)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("unable to fetch %s: unexpected status %s", key, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	return HTTPContent{Body: body, ContentType: resp.Header.Get("Content-Type")}, true, nil
+}
+
+// nomadServiceDataSource reads service registrations from Nomad.
+type nomadServiceDataSource struct {
+	services *nomad.Services
+}
+
+func newNomadServiceDataSource(config *structs.ClientConfig) (DataSource, error) {
+	c, err := client.NewNomadClient(config.Addr)
+	if err != nil {
+		return nil, err
+	}
+	return &nomadServiceDataSource{services: c.Services()}, nil
+}
+
+func (n *nomadServiceDataSource) Get(key string) (interface{}, bool, error) {
+	services, _, err := n.services.Get(key, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	return services, true, nil
+}
+
+// nomadVarDataSource reads Nomad Variables.
+type nomadVarDataSource struct {
+	variables *nomad.Variables
+}
+
+func newNomadVarDataSource(config *structs.ClientConfig) (DataSource, error) {
+	c, err := client.NewNomadClient(config.Addr)
+	if err != nil {
+		return nil, err
+	}
+	return &nomadVarDataSource{variables: c.Variables()}, nil
+}
+
+func (n *nomadVarDataSource) Get(key string) (interface{}, bool, error) {
+	v, _, err := n.variables.Peek(key, nil)
+	if err != nil || v == nil {
+		return nil, false, err
+	}
+	return v.Items, true, nil
+}
+
+// timeDataSource returns the current time for any key.
+type timeDataSource struct{}
+
+func newTimeDataSource(_ *structs.ClientConfig) (DataSource, error) {
+	return timeDataSource{}, nil
+}
+
+func (timeDataSource) Get(_ string) (interface{}, bool, error) {
+	return time.Now(), true, nil
+}
+
+// vaultDataSource reads secrets from Vault using the VAULT_ADDR, VAULT_TOKEN
+// and VAULT_NAMESPACE environment variables. Secrets stored in a KV version 2
+// engine have their data unwrapped, so both versions return the secret
+// key/value pairs.
+type vaultDataSource struct {
+	addr      string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+func newVaultDataSource(_ *structs.ClientConfig) (DataSource, error) {
+
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		return nil, errors.New("VAULT_TOKEN must be set to read secrets from Vault")
+	}
+
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		addr = defaultVaultAddr
+	}
+
+	return &vaultDataSource{
+		addr:      strings.TrimSuffix(addr, "/"),
+		token:     token,
+		namespace: os.Getenv("VAULT_NAMESPACE"),
+		client:    &http.Client{Timeout: httpDataSourceTimeout},
+	}, nil
+}
+
+func (v *vaultDataSource) Get(key string) (interface{}, bool, error) {
+
+	req, err := http.NewRequest(http.MethodGet, v.addr+"/v1/"+strings.TrimPrefix(key, "/"), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	var secret struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []string               `json:"errors"`
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		_ = json.NewDecoder(resp.Body).Decode(&secret)
+		if len(secret.Errors) > 0 {
+			return nil, false, fmt.Errorf("unable to read Vault secret %s: %s", key, strings.Join(secret.Errors, ", "))
+		}
+		return nil, false, fmt.Errorf("unable to read Vault secret %s: unexpected status %s", key, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, false, err
+	}
+
+	// KV version 2 secrets nest the secret data alongside its metadata.
+	if data, ok := secret.Data["data"].(map[string]interface{}); ok {
+		if _, ok := secret.Data["metadata"]; ok {
+			return data, true, nil
+		}
+	}
+	if secret.Data == nil {
+		return nil, false, nil
+	}
+	return secret.Data, true, nil
+}
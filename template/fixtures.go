@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// Fixtures hold fixed values which are used in place of the external lookups
+// made while rendering a template, allowing templates to be rendered without
+// network access. Lookups of Consul KV keys, environment variables and files
+// which are not included in the fixtures behave as if they do not exist,
+// while lookups against all other data sources fail.
+type Fixtures struct {
+	// ConsulKV maps Consul KV keys to their values.
+	ConsulKV map[string]string
+
+	// Env maps environment variable names to their values.
+	Env map[string]string
+
+	// Files maps file paths, as passed to the fileContents function, to their
+	// contents.
+	Files map[string]string
+}
+
+// dataSources returns the data sources which serve the fixture values.
+func (f *Fixtures) dataSources() map[string]DataSource {
+	return map[string]DataSource{
+		DataSourceConsul: stringMapDataSource(f.ConsulKV),
+		DataSourceEnv:    stringMapDataSource(f.Env),
+		DataSourceFile:   stringMapDataSource(f.Files),
+		DataSourceTime:   timeDataSource{},
+	}
+}
+
+// stringMapDataSource converts a map of string values into a MapDataSource.
+func stringMapDataSource(values map[string]string) MapDataSource {
+	m := make(MapDataSource, len(values))
+	for k, v := range values {
+		m[k] = v
+	}
+	return m
+}
+
+// offlineDataSources returns the data sources available when rendering
+// offline. The environment, local files and time can be read as normal, while
+// Consul KV lookups are served from the fixture file if one is passed.
+func offlineDataSources(consulFixtureFile string) (map[string]DataSource, error) {
+
+	sources := map[string]DataSource{
+		DataSourceEnv:  envDataSource{},
+		DataSourceFile: fileDataSource{},
+		DataSourceTime: timeDataSource{},
+	}
+
+	if consulFixtureFile != "" {
+		kv, err := loadConsulFixture(consulFixtureFile)
+		if err != nil {
+			return nil, err
+		}
+		sources[DataSourceConsul] = kv
+	}
+	return sources, nil
+}
+
+// loadConsulFixture reads a YAML or JSON file of Consul KV keys and values.
+// Nested maps are joined using the "/" delimiter to build the full key, so
+// that fixtures can mirror the layout of the KV store.
+func loadConsulFixture(path string) (MapDataSource, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	if err := yaml3.Unmarshal(b, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse consul fixture file %s: %v", path, err)
+	}
+
+	kv := make(MapDataSource)
+	if err := flattenConsulFixture(kv, "", values); err != nil {
+		return nil, fmt.Errorf("invalid consul fixture file %s: %v", path, err)
+	}
+	return kv, nil
+}
+
+func flattenConsulFixture(kv MapDataSource, prefix string, values map[string]interface{}) error {
+	for k, v := range values {
+		key := strings.TrimPrefix(prefix+"/"+strings.Trim(k, "/"), "/")
+
+		switch typed := v.(type) {
+		case map[string]interface{}:
+			if err := flattenConsulFixture(kv, key, typed); err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("key %s must be a string or map, not a list", key)
+		case nil:
+			kv[key] = ""
+		default:
+			kv[key] = fmt.Sprint(typed)
+		}
+	}
+	return nil
+}
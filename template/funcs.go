@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"math"
 	"os"
 	"reflect"
@@ -19,31 +20,37 @@ import (
 
 	"github.com/Masterminds/sprig/v3"
 	spewLib "github.com/davecgh/go-spew/spew"
-	consul "github.com/hashicorp/consul/api"
+	nomad "github.com/hashicorp/nomad/api"
 	"github.com/rs/zerolog/log"
 )
 
-// funcMap builds the template functions and passes the consulClient where this
-// is required.
-func funcMap(consulClient *consul.Client) template.FuncMap {
+// funcMap builds the template functions, passing the data sources of the
+// render to those which read from external systems.
+func funcMap(sources *DataSources) template.FuncMap {
 	r := template.FuncMap{
-		"consulKey":          consulKeyFunc(consulClient),
-		"consulKeyExists":    consulKeyExistsFunc(consulClient),
-		"consulKeyOrDefault": consulKeyOrDefaultFunc(consulClient),
-		"env":                envFunc(),
-		"fileContents":       fileContents(),
+		"consulKey":          consulKeyFunc(sources),
+		"consulKeyExists":    consulKeyExistsFunc(sources),
+		"consulKeyOrDefault": consulKeyOrDefaultFunc(sources),
+		"env":                envFunc(sources),
+		"fileContents":       fileContents(sources),
 		"loop":               loop,
+		"nomadService":       nomadServiceFunc(sources),
+		"nomadVar":           nomadVarFunc(sources),
+		"nomadVarExists":     nomadVarExistsFunc(sources),
+		"nomadVarOrDefault":  nomadVarOrDefaultFunc(sources),
 		"parseBool":          parseBool,
 		"parseFloat":         parseFloat,
 		"parseInt":           parseInt,
 		"parseJSON":          parseJSON,
 		"parseUint":          parseUint,
 		"replace":            replace,
-		"timeNow":            timeNowFunc,
-		"timeNowUTC":         timeNowUTCFunc,
-		"timeNowTimezone":    timeNowTimezoneFunc(),
+		"sensitive":          sensitiveFunc,
+		"timeNow":            timeNowFunc(sources),
+		"timeNowUTC":         timeNowUTCFunc(sources),
+		"timeNowTimezone":    timeNowTimezoneFunc(sources),
 		"toLower":            toLower,
 		"toUpper":            toUpper,
+		"vaultSecret":        vaultSecretFunc(sources),
 
 		// Maths.
 		"add":      add,
@@ -78,6 +85,28 @@ func funcMap(consulClient *consul.Client) template.FuncMap {
 	}
 	r["sprigVersion"] = sprigVersionFunc
 
+	// The Sprig environment functions read the process environment directly,
+	// so are replaced to read from the environment data source instead.
+	r["env"] = func(s string) (string, error) {
+		v, _, err := getDataSourceValue[string](sources, DataSourceEnv, s)
+		return v, err
+	}
+	r["expandenv"] = func(s string) (string, error) {
+		var err error
+		expanded := os.Expand(s, func(k string) string {
+			v, _, lookupErr := getDataSourceValue[string](sources, DataSourceEnv, k)
+			if lookupErr != nil && err == nil {
+				err = lookupErr
+			}
+			return v
+		})
+		return expanded, err
+	}
+
+	for k, v := range registeredFuncs(sources) {
+		r[k] = v
+	}
+
 	return r
 }
 
@@ -92,43 +121,41 @@ func sprigVersionFunc() func(string) (string, error) {
 	}
 }
 
-func consulKeyFunc(consulClient *consul.Client) func(string) (string, error) {
+func consulKeyFunc(sources *DataSources) func(string) (string, error) {
 	return func(s string) (string, error) {
 
 		if len(s) == 0 {
 			return "", nil
 		}
 
-		kv, _, err := consulClient.KV().Get(s, nil)
+		v, ok, err := getDataSourceValue[string](sources, DataSourceConsul, s)
 		if err != nil {
 			return "", err
 		}
 
-		if kv == nil {
+		if !ok {
 			return "", errors.New("Consul KV not found")
 		}
 
-		v := string(kv.Value[:])
-		log.Info().Msgf("template/funcs: using Consul KV variable with key %s and value %s",
-			s, v)
+		log.Info().Msgf("template/funcs: using Consul KV variable with key %s", s)
 
 		return v, nil
 	}
 }
 
-func consulKeyExistsFunc(consulClient *consul.Client) func(string) (bool, error) {
+func consulKeyExistsFunc(sources *DataSources) func(string) (bool, error) {
 	return func(s string) (bool, error) {
 
 		if len(s) == 0 {
 			return false, nil
 		}
 
-		kv, _, err := consulClient.KV().Get(s, nil)
+		_, ok, err := getDataSourceValue[string](sources, DataSourceConsul, s)
 		if err != nil {
 			return false, err
 		}
 
-		if kv == nil {
+		if !ok {
 			return false, nil
 		}
 
@@ -138,32 +165,148 @@ func consulKeyExistsFunc(consulClient *consul.Client) func(string) (bool, error)
 	}
 }
 
-func consulKeyOrDefaultFunc(consulClient *consul.Client) func(string, string) (string, error) {
+func consulKeyOrDefaultFunc(sources *DataSources) func(string, string) (string, error) {
 	return func(s, d string) (string, error) {
 
 		if len(s) == 0 {
-			log.Info().Msgf("template/funcs: using default Consul KV variable with value %s", d)
+			log.Info().Msg("template/funcs: using default Consul KV variable as no key was passed")
 			return d, nil
 		}
 
-		kv, _, err := consulClient.KV().Get(s, nil)
+		v, ok, err := getDataSourceValue[string](sources, DataSourceConsul, s)
 		if err != nil {
 			return "", err
 		}
 
-		if kv == nil {
-			log.Info().Msgf("template/funcs: using default Consul KV variable with value %s", d)
+		if !ok {
+			log.Info().Msgf("template/funcs: using default Consul KV variable for key %s", s)
 			return d, nil
 		}
 
-		v := string(kv.Value[:])
-		log.Info().Msgf("template/funcs: using Consul KV variable with key %s and value %s",
-			s, v)
+		log.Info().Msgf("template/funcs: using Consul KV variable with key %s", s)
 
 		return v, nil
 	}
 }
 
+func nomadServiceFunc(sources *DataSources) func(string) ([]*nomad.ServiceRegistration, error) {
+	return func(s string) ([]*nomad.ServiceRegistration, error) {
+
+		if len(s) == 0 {
+			return nil, nil
+		}
+
+		services, _, err := getDataSourceValue[[]*nomad.ServiceRegistration](sources, DataSourceNomadService, s)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Info().Msgf("template/funcs: found %v Nomad service registrations with name %s",
+			len(services), s)
+
+		return services, nil
+	}
+}
+
+func nomadVarFunc(sources *DataSources) func(string) (map[string]string, error) {
+	return func(s string) (map[string]string, error) {
+
+		if len(s) == 0 {
+			return map[string]string{}, nil
+		}
+
+		items, ok, err := getDataSourceValue[map[string]string](sources, DataSourceNomadVar, s)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, errors.New("Nomad variable not found")
+		}
+
+		log.Info().Msgf("template/funcs: using Nomad variable with path %s", s)
+
+		return items, nil
+	}
+}
+
+func nomadVarExistsFunc(sources *DataSources) func(string) (bool, error) {
+	return func(s string) (bool, error) {
+
+		if len(s) == 0 {
+			return false, nil
+		}
+
+		_, ok, err := getDataSourceValue[map[string]string](sources, DataSourceNomadVar, s)
+		if err != nil {
+			return false, err
+		}
+
+		if !ok {
+			return false, nil
+		}
+
+		log.Info().Msgf("template/funcs: found Nomad variable with path %s", s)
+
+		return true, nil
+	}
+}
+
+func nomadVarOrDefaultFunc(sources *DataSources) func(string, string, string) (string, error) {
+	return func(s, k, d string) (string, error) {
+
+		if len(s) == 0 || len(k) == 0 {
+			log.Info().Msg("template/funcs: using default Nomad variable item as no path or item was passed")
+			return d, nil
+		}
+
+		items, ok, err := getDataSourceValue[map[string]string](sources, DataSourceNomadVar, s)
+		if err != nil {
+			return "", err
+		}
+
+		if !ok {
+			log.Info().Msgf("template/funcs: using default Nomad variable item for path %s and item %s", s, k)
+			return d, nil
+		}
+
+		item, ok := items[k]
+		if !ok {
+			log.Info().Msgf("template/funcs: using default Nomad variable item for path %s and item %s", s, k)
+			return d, nil
+		}
+
+		log.Info().Msgf("template/funcs: using Nomad variable with path %s and item %s", s, k)
+
+		return item, nil
+	}
+}
+
+// vaultSecretFunc returns the data of the Vault secret at the path. All
+// values of the secret are marked as sensitive.
+func vaultSecretFunc(sources *DataSources) func(string) (map[string]interface{}, error) {
+	return func(s string) (map[string]interface{}, error) {
+
+		if len(s) == 0 {
+			return map[string]interface{}{}, nil
+		}
+
+		data, ok, err := getDataSourceValue[map[string]interface{}](sources, DataSourceVault, s)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, errors.New("Vault secret not found")
+		}
+
+		markSensitiveValue(data)
+		log.Info().Msgf("template/funcs: using Vault secret with path %s", s)
+
+		return data, nil
+	}
+}
+
 func loop(ints ...int64) (<-chan int64, error) {
 	var start, stop int64
 	switch len(ints) {
@@ -252,15 +395,34 @@ func replace(input, from, to string) string {
 	return strings.Replace(input, from, to, -1)
 }
 
-func timeNowFunc() string {
-	return time.Now().Format("2006-01-02T15:04:05Z07:00")
+// sensitiveFunc marks the passed value as sensitive, masking it within all
+// log output, and returns it unchanged for use within the template.
+func sensitiveFunc(v interface{}) interface{} {
+	markSensitiveValue(v)
+	return v
+}
+
+func timeNowFunc(sources *DataSources) func() (string, error) {
+	return func() (string, error) {
+		now, err := currentTime(sources)
+		if err != nil {
+			return "", err
+		}
+		return now.Format("2006-01-02T15:04:05Z07:00"), nil
+	}
 }
 
-func timeNowUTCFunc() string {
-	return time.Now().UTC().Format("2006-01-02T15:04:05Z07:00")
+func timeNowUTCFunc(sources *DataSources) func() (string, error) {
+	return func() (string, error) {
+		now, err := currentTime(sources)
+		if err != nil {
+			return "", err
+		}
+		return now.UTC().Format("2006-01-02T15:04:05Z07:00"), nil
+	}
 }
 
-func timeNowTimezoneFunc() func(string) (string, error) {
+func timeNowTimezoneFunc(sources *DataSources) func(string) (string, error) {
 	return func(t string) (string, error) {
 
 		if t == "" {
@@ -272,10 +434,21 @@ func timeNowTimezoneFunc() func(string) (string, error) {
 			return "", err
 		}
 
-		return time.Now().In(loc).Format("2006-01-02T15:04:05Z07:00"), nil
+		now, err := currentTime(sources)
+		if err != nil {
+			return "", err
+		}
+
+		return now.In(loc).Format("2006-01-02T15:04:05Z07:00"), nil
 	}
 }
 
+// currentTime returns the current time from the time data source.
+func currentTime(sources *DataSources) (time.Time, error) {
+	now, _, err := getDataSourceValue[time.Time](sources, DataSourceTime, timeDataSourceKey)
+	return now, err
+}
+
 func toLower(s string) (string, error) {
 	return strings.ToLower(s), nil
 }
@@ -284,25 +457,29 @@ func toUpper(s string) (string, error) {
 	return strings.ToUpper(s), nil
 }
 
-func envFunc() func(string) (string, error) {
+func envFunc(sources *DataSources) func(string) (string, error) {
 	return func(s string) (string, error) {
 		if s == "" {
 			return "", nil
 		}
-		return os.Getenv(s), nil
+		v, _, err := getDataSourceValue[string](sources, DataSourceEnv, s)
+		return v, err
 	}
 }
 
-func fileContents() func(string) (string, error) {
+func fileContents(sources *DataSources) func(string) (string, error) {
 	return func(s string) (string, error) {
 		if s == "" {
 			return "", nil
 		}
-		contents, err := os.ReadFile(s)
+		contents, ok, err := getDataSourceValue[string](sources, DataSourceFile, s)
 		if err != nil {
 			return "", err
 		}
-		return string(contents), nil
+		if !ok {
+			return "", &fs.PathError{Op: "open", Path: s, Err: fs.ErrNotExist}
+		}
+		return contents, nil
 	}
 }
 
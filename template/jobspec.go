@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/jobspec2"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// JobFormatHCL is the HCL job specification format, which includes the
+	// HCL JSON syntax.
+	JobFormatHCL = "hcl"
+
+	// JobFormatJSON is the Nomad API JSON job format, as output by
+	// `nomad job inspect` and accepted by the jobs API.
+	JobFormatJSON = "json"
+)
+
+// parseJob parses the rendered job template into a Nomad job using the passed
+// job format, detecting the format if it is empty.
+func parseJob(templateFile, format string, tpl *bytes.Buffer, variables map[string]interface{}, environ []string) (*nomad.Job, error) {
+
+	if format == "" {
+		format = detectJobFormat(tpl.Bytes())
+		log.Debug().Msgf("template/jobspec: detected %s job format", format)
+	}
+
+	switch format {
+	case JobFormatHCL:
+		return parseHCLJob(templateFile, tpl, variables, environ)
+	case JobFormatJSON:
+		return parseJSONJob(tpl.Bytes())
+	default:
+		return nil, fmt.Errorf("job format %q not supported", format)
+	}
+}
+
+// detectJobFormat returns the format of the rendered job. Output which is a
+// JSON object is treated as the API JSON format unless it holds the job or
+// variable blocks of the HCL JSON syntax.
+func detectJobFormat(src []byte) string {
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(src, &obj); err != nil {
+		return JobFormatHCL
+	}
+
+	for _, block := range []string{"job", "variable", "locals"} {
+		if _, ok := obj[block]; ok {
+			return JobFormatHCL
+		}
+	}
+	return JobFormatJSON
+}
+
+// parseHCLJob parses the rendered job as HCL. The resolved Levant variables
+// are also passed to the jobspec parser, allowing the job to declare HCL2
+// variable blocks and reference them using var.<name>. Levant variables which
+// the job does not declare are ignored, so templates can mix Levant templating
+// with native HCL2 variables. Values can also be set using NOMAD_VAR_<name>
+// environment variables, which Levant variables override.
+func parseHCLJob(templateFile string, tpl *bytes.Buffer, variables map[string]interface{}, environ []string) (*nomad.Job, error) {
+
+	varContent, err := hclVariableContent(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	return jobspec2.ParseWithConfig(&jobspec2.ParseConfig{
+		Path:       templateFile,
+		Body:       tpl.Bytes(),
+		VarContent: varContent,
+		Envs:       environ,
+		Strict:     false,
+	})
+}
+
+// parseJSONJob decodes the rendered job from the Nomad API JSON format, which
+// can either be the job itself or the job wrapped within a Job key as used by
+// the job register API.
+func parseJSONJob(src []byte) (*nomad.Job, error) {
+
+	var wrapper struct {
+		Job *nomad.Job
+	}
+	if err := json.Unmarshal(src, &wrapper); err != nil {
+		return nil, fmt.Errorf("unable to decode JSON job: %v", err)
+	}
+
+	job := wrapper.Job
+	if job == nil {
+		job = &nomad.Job{}
+		if err := json.Unmarshal(src, job); err != nil {
+			return nil, fmt.Errorf("unable to decode JSON job: %v", err)
+		}
+	}
+
+	// Match the jobspec parser, which defaults the ID and name of the job from
+	// each other.
+	switch {
+	case job.ID == nil && job.Name == nil:
+		return nil, fmt.Errorf("JSON job must include an ID or Name")
+	case job.ID == nil:
+		job.ID = job.Name
+	case job.Name == nil:
+		job.Name = job.ID
+	}
+
+	return job, nil
+}
+
+// hclVariableContent encodes the variables as an HCL JSON variables file. The
+// values are decoded literally, so interpolation sequences such as
+// ${NOMAD_ALLOC_DIR} within values are passed through to the job unchanged.
+func hclVariableContent(variables map[string]interface{}) (string, error) {
+
+	if len(variables) == 0 {
+		return "", nil
+	}
+
+	out, err := json.Marshal(normalizeVariable(variables))
+	if err != nil {
+		return "", fmt.Errorf("unable to encode variables for the jobspec: %v", err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,360 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/levant/helper"
+	nomad "github.com/hashicorp/nomad/api"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultLockFile is the path of the lockfile used when none is set.
+	DefaultLockFile = "levant.lock"
+
+	// lockFileVersion is the version of the lockfile format written by this
+	// version of Levant.
+	lockFileVersion = 1
+)
+
+// lockFile is the on disk format of the lockfile.
+type lockFile struct {
+	Version int             `json:"version"`
+	Lookups []*lockedLookup `json:"lookups"`
+}
+
+// lockedLookup is the recorded result of a single lookup against a data
+// source. The values of sensitive lookups are not recorded.
+type lockedLookup struct {
+	Source    string          `json:"source"`
+	Key       string          `json:"key"`
+	List      bool            `json:"list,omitempty"`
+	Found     bool            `json:"found"`
+	Sensitive bool            `json:"sensitive,omitempty"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	Hash      string          `json:"hash,omitempty"`
+	Time      time.Time       `json:"time"`
+}
+
+// lookupLock records the lookups made against the data sources of a render,
+// or replays those recorded by a previous render while reporting any which
+// have since changed upstream.
+type lookupLock struct {
+	path   string
+	replay bool
+
+	lock    sync.Mutex
+	lookups map[string]*lockedLookup
+	drifted int
+}
+
+// newLookupLock returns a lookupLock using the lockfile at the passed path,
+// which is read when replaying.
+func newLookupLock(path string, replay bool) (*lookupLock, error) {
+
+	l := &lookupLock{
+		path:    path,
+		replay:  replay,
+		lookups: make(map[string]*lockedLookup),
+	}
+
+	if !replay {
+		return l, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read lockfile: %v", err)
+	}
+
+	var f lockFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %v", path, err)
+	}
+	if f.Version != lockFileVersion {
+		return nil, fmt.Errorf("lockfile %s has unsupported version %v", path, f.Version)
+	}
+
+	for _, lookup := range f.Lookups {
+		l.lookups[lookupID(lookup.Source, lookup.Key, lookup.List)] = lookup
+	}
+
+	log.Debug().Msgf("template/lock: loaded %v lookups from lockfile %s", len(f.Lookups), path)
+
+	return l, nil
+}
+
+// get performs a lookup of the key within the named data source, either
+// recording the result or replaying it from the lockfile.
+func (l *lookupLock) get(d *DataSources, name, key string) (interface{}, bool, error) {
+
+	if !l.replay {
+		v, ok, err := d.get(name, key)
+		if err == nil {
+			l.record(name, key, false, v, ok)
+		}
+		return v, ok, err
+	}
+
+	lookup, err := l.lookup(name, key, false)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Sensitive values are not recorded, so are always looked up.
+	if lookup.Sensitive {
+		v, ok, err := d.get(name, key)
+		if err != nil {
+			return nil, false, err
+		}
+		l.checkDrift(lookup, v, ok)
+		return v, ok, nil
+	}
+
+	v, err := decodeLockedValue(lookup)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if name != DataSourceTime && !d.offline {
+		if live, ok, err := d.get(name, key); err != nil {
+			log.Warn().Err(err).Msgf("template/lock: unable to check %s key %s for drift", name, key)
+		} else {
+			l.checkDrift(lookup, live, ok)
+		}
+	}
+
+	return v, lookup.Found, nil
+}
+
+// list performs a listing of the prefix within the named data source, either
+// recording the result or replaying it from the lockfile. The environment is
+// never recorded as a whole.
+func (l *lookupLock) list(d *DataSources, name, prefix string) (map[string]interface{}, error) {
+
+	if name == DataSourceEnv {
+		return d.list(name, prefix)
+	}
+
+	if !l.replay {
+		values, err := d.list(name, prefix)
+		if err == nil {
+			if values == nil {
+				values = make(map[string]interface{})
+			}
+			l.record(name, prefix, true, values, true)
+		}
+		return values, err
+	}
+
+	lookup, err := l.lookup(name, prefix, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if lookup.Sensitive {
+		values, err := d.list(name, prefix)
+		if err != nil {
+			return nil, err
+		}
+		l.checkDrift(lookup, values, true)
+		return values, nil
+	}
+
+	v, err := decodeLockedValue(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	if !d.offline {
+		if live, err := d.list(name, prefix); err != nil {
+			log.Warn().Err(err).Msgf("template/lock: unable to check %s prefix %s for drift", name, prefix)
+		} else {
+			l.checkDrift(lookup, live, true)
+		}
+	}
+
+	values, ok := v.(map[string]interface{})
+	if !ok || values == nil {
+		return nil, fmt.Errorf("lockfile %s has a malformed %s listing of %s", l.path, name, prefix)
+	}
+	return values, nil
+}
+
+// lookup returns the recorded lookup, failing if the lookup was not made by
+// the render which wrote the lockfile.
+func (l *lookupLock) lookup(name, key string, list bool) (*lockedLookup, error) {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	lookup, ok := l.lookups[lookupID(name, key, list)]
+	if !ok {
+		return nil, fmt.Errorf("%s lookup of %s is not recorded in lockfile %s", name, key, l.path)
+	}
+	return lookup, nil
+}
+
+// record stores the result of a lookup. Only the first result of repeated
+// lookups is kept, as this is the value the template was rendered with. The
+// values of sensitive lookups are never stored, nor is a hash of them, as a
+// hash of a low entropy secret could be reversed.
+func (l *lookupLock) record(name, key string, list bool, v interface{}, found bool) {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	id := lookupID(name, key, list)
+	if _, ok := l.lookups[id]; ok {
+		return
+	}
+
+	lookup := &lockedLookup{Source: name, Key: key, List: list, Found: found, Time: time.Now().UTC()}
+	if sensitiveDataSource(name) || helper.IsSensitiveName(key) {
+		lookup.Sensitive = true
+	}
+	if found && !lookup.Sensitive {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			log.Warn().Err(err).Msgf("template/lock: unable to record %s lookup of %s", name, key)
+			return
+		}
+		lookup.Value = raw
+		lookup.Hash = "sha256:" + sha256Hex(raw)
+	}
+	l.lookups[id] = lookup
+}
+
+// checkDrift compares the live result of a lookup with the recorded result,
+// logging a warning if it has changed. Only whether sensitive lookups were
+// found can be compared, as their values are not recorded.
+func (l *lookupLock) checkDrift(lookup *lockedLookup, v interface{}, found bool) {
+
+	var hash string
+	if found && !lookup.Sensitive {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		hash = "sha256:" + sha256Hex(raw)
+	}
+
+	if found == lookup.Found && hash == lookup.Hash {
+		return
+	}
+
+	l.lock.Lock()
+	l.drifted++
+	l.lock.Unlock()
+
+	log.Warn().Msgf("template/lock: %s key %s has changed since it was locked at %s",
+		lookup.Source, lookup.Key, lookup.Time.Format(time.RFC3339))
+}
+
+// finish writes the recorded lookups to the lockfile, or reports a summary of
+// any drift found when replaying.
+func (l *lookupLock) finish() error {
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.replay {
+		if l.drifted > 0 {
+			log.Warn().Msgf("template/lock: %v lookups have changed upstream since lockfile %s was written",
+				l.drifted, l.path)
+		}
+		return nil
+	}
+
+	f := lockFile{Version: lockFileVersion, Lookups: make([]*lockedLookup, 0, len(l.lookups))}
+	for _, lookup := range l.lookups {
+
+		// The values of sensitive variables are registered once the template has
+		// rendered, so are checked again when writing.
+		if len(lookup.Value) > 0 && helper.Redact(string(lookup.Value)) != string(lookup.Value) {
+			lookup.Sensitive = true
+			lookup.Value = nil
+			lookup.Hash = ""
+		}
+		f.Lookups = append(f.Lookups, lookup)
+	}
+	sort.Slice(f.Lookups, func(i, j int) bool {
+		return lookupID(f.Lookups[i].Source, f.Lookups[i].Key, f.Lookups[i].List) <
+			lookupID(f.Lookups[j].Source, f.Lookups[j].Key, f.Lookups[j].List)
+	})
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(l.path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("unable to write lockfile: %v", err)
+	}
+
+	log.Info().Msgf("template/lock: recorded %v lookups to lockfile %s", len(f.Lookups), l.path)
+
+	return nil
+}
+
+// sensitiveDataSource returns whether the values of the data source may hold
+// secrets and so are never written to the lockfile. This includes HTTP
+// responses, as their encoded bodies would not be matched when redacting.
+func sensitiveDataSource(name string) bool {
+	switch name {
+	case DataSourceHTTP, DataSourceNomadVar, DataSourceVault:
+		return true
+	default:
+		return false
+	}
+}
+
+// lookupID uniquely identifies a lookup within the lockfile.
+func lookupID(name, key string, list bool) string {
+	if list {
+		return name + "/list:" + key
+	}
+	return name + ":" + key
+}
+
+// decodeLockedValue decodes the recorded value of a lookup into the type
+// returned by the data source. Values of sources not included with Levant are
+// decoded into their generic JSON types.
+func decodeLockedValue(lookup *lockedLookup) (interface{}, error) {
+
+	if !lookup.Found {
+		return nil, nil
+	}
+
+	var v interface{}
+	switch {
+	case lookup.List:
+		v = &map[string]interface{}{}
+	case lookup.Source == DataSourceConsul, lookup.Source == DataSourceEnv, lookup.Source == DataSourceFile:
+		v = new(string)
+	case lookup.Source == DataSourceHTTP:
+		v = &HTTPContent{}
+	case lookup.Source == DataSourceNomadService:
+		v = &[]*nomad.ServiceRegistration{}
+	case lookup.Source == DataSourceNomadVar:
+		v = &map[string]string{}
+	case lookup.Source == DataSourceTime:
+		v = &time.Time{}
+	default:
+		v = new(interface{})
+	}
+
+	if err := json.Unmarshal(lookup.Value, v); err != nil {
+		return nil, fmt.Errorf("failed to decode locked %s lookup of %s: %v", lookup.Source, lookup.Key, err)
+	}
+	return reflect.ValueOf(v).Elem().Interface(), nil
+}
@@ -0,0 +1,206 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/levant/helper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock_RecordAndReplay(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), DefaultLockFile)
+	helper.MarkSensitive("l0ck-s3cr3t")
+	t.Cleanup(helper.ResetSensitive)
+
+	upstream := map[string]DataSource{
+		DataSourceConsul: MapDataSource{
+			"app/image":    "redis:7",
+			"app/password": "l0ck-s3cr3t",
+			"app/cfg/a":    "1",
+		},
+		DataSourceTime: MapDataSource{timeDataSourceKey: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+
+	// Record the lookups, including a missing key and a repeated lookup.
+	d := newDataSources(nil, upstream, true)
+	lookups, err := newLookupLock(path, false)
+	require.NoError(t, err)
+	d.lookups = lookups
+
+	for _, key := range []string{"app/image", "app/image", "app/password", "app/missing"} {
+		_, _, err = d.Get(DataSourceConsul, key)
+		require.NoError(t, err)
+	}
+	_, err = d.List(DataSourceConsul, "app/cfg")
+	require.NoError(t, err)
+	_, err = currentTime(d)
+	require.NoError(t, err)
+	require.NoError(t, lookups.finish())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "l0ck-s3cr3t")
+
+	var f lockFile
+	require.NoError(t, json.Unmarshal(b, &f))
+	require.Len(t, f.Lookups, 5)
+	require.Equal(t, "app/cfg", f.Lookups[0].Key)
+	require.True(t, f.Lookups[0].List)
+	require.True(t, f.Lookups[3].Sensitive)
+	require.Empty(t, f.Lookups[3].Value)
+
+	// Replay the lookups after the upstream values have changed.
+	upstream[DataSourceConsul] = MapDataSource{
+		"app/image":    "redis:8",
+		"app/password": "l0ck-s3cr3t",
+		"app/missing":  "now set",
+		"app/cfg/a":    "1",
+	}
+	upstream[DataSourceTime] = MapDataSource{timeDataSourceKey: time.Now()}
+
+	d = newDataSources(nil, upstream, false)
+	lookups, err = newLookupLock(path, true)
+	require.NoError(t, err)
+	d.lookups = lookups
+
+	v, ok, err := d.Get(DataSourceConsul, "app/image")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "redis:7", v)
+
+	_, ok, err = d.Get(DataSourceConsul, "app/missing")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	v, ok, err = d.Get(DataSourceConsul, "app/password")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "l0ck-s3cr3t", v)
+
+	values, err := d.List(DataSourceConsul, "app/cfg")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"app/cfg/a": "1"}, values)
+
+	now, err := currentTime(d)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), now)
+
+	require.Equal(t, 2, lookups.drifted)
+	require.NoError(t, lookups.finish())
+
+	// Lookups not made by the locked render fail.
+	_, _, err = d.Get(DataSourceConsul, "app/other")
+	require.EqualError(t, err, "consul lookup of app/other is not recorded in lockfile "+path)
+}
+
+func TestLock_SensitiveDataSources(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), DefaultLockFile)
+
+	upstream := map[string]DataSource{
+		DataSourceNomadVar: MapDataSource{"app/config": map[string]string{"db_pass": "n0mad-s3cr3t"}},
+		DataSourceHTTP:     MapDataSource{"https://example.com/config": "http-s3cr3t"},
+	}
+
+	d := newDataSources(nil, upstream, true)
+	lookups, err := newLookupLock(path, false)
+	require.NoError(t, err)
+	d.lookups = lookups
+
+	_, _, err = d.Get(DataSourceNomadVar, "app/config")
+	require.NoError(t, err)
+	_, _, err = d.Get(DataSourceHTTP, "https://example.com/config")
+	require.NoError(t, err)
+	require.NoError(t, lookups.finish())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "n0mad-s3cr3t")
+	require.NotContains(t, string(b), "http-s3cr3t")
+
+	var f lockFile
+	require.NoError(t, json.Unmarshal(b, &f))
+	require.Len(t, f.Lookups, 2)
+	for _, lookup := range f.Lookups {
+		require.True(t, lookup.Sensitive)
+		require.Empty(t, lookup.Value)
+		require.Empty(t, lookup.Hash)
+	}
+
+	// Sensitive lookups are made live when replaying.
+	d = newDataSources(nil, upstream, false)
+	lookups, err = newLookupLock(path, true)
+	require.NoError(t, err)
+	d.lookups = lookups
+
+	v, ok, err := d.Get(DataSourceNomadVar, "app/config")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, map[string]string{"db_pass": "n0mad-s3cr3t"}, v)
+	require.Equal(t, 0, lookups.drifted)
+}
+
+func TestLock_SensitiveNames(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), DefaultLockFile)
+
+	upstream := map[string]DataSource{
+		DataSourceConsul: MapDataSource{"app/db_password": "c0nsul-s3cr3t"},
+		DataSourceEnv:    MapDataSource{"VAULT_TOKEN": "hvs.3nv-s3cr3t"},
+	}
+
+	d := newDataSources(nil, upstream, true)
+	lookups, err := newLookupLock(path, false)
+	require.NoError(t, err)
+	d.lookups = lookups
+
+	_, _, err = d.Get(DataSourceConsul, "app/db_password")
+	require.NoError(t, err)
+	_, _, err = d.Get(DataSourceEnv, "VAULT_TOKEN")
+	require.NoError(t, err)
+	require.NoError(t, lookups.finish())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "c0nsul-s3cr3t")
+	require.NotContains(t, string(b), "3nv-s3cr3t")
+
+	var f lockFile
+	require.NoError(t, json.Unmarshal(b, &f))
+	require.Len(t, f.Lookups, 2)
+	for _, lookup := range f.Lookups {
+		require.True(t, lookup.Sensitive)
+		require.True(t, lookup.Found)
+		require.Empty(t, lookup.Value)
+		require.Empty(t, lookup.Hash)
+	}
+}
+
+func TestLock_MalformedListing(t *testing.T) {
+
+	upstream := map[string]DataSource{DataSourceConsul: MapDataSource{}}
+
+	for _, entry := range []string{
+		`{"source": "consul", "key": "app", "list": true, "found": true, "value": null}`,
+		`{"source": "consul", "key": "app", "list": true, "found": false}`,
+	} {
+		path := filepath.Join(t.TempDir(), DefaultLockFile)
+		require.NoError(t, os.WriteFile(path, []byte(`{"version": 1, "lookups": [`+entry+`]}`), 0644))
+
+		d := newDataSources(nil, upstream, true)
+		lookups, err := newLookupLock(path, true)
+		require.NoError(t, err)
+		d.lookups = lookups
+
+		_, err = d.List(DataSourceConsul, "app")
+		require.EqualError(t, err, "lockfile "+path+" has a malformed consul listing of app")
+	}
+}
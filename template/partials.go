@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// partialExtension is the file extension of the partials loaded from each
+	// template path directory.
+	partialExtension = ".tpl"
+
+	// maxIncludeDepth limits the nesting of include and tpl calls, protecting
+	// against partials which recursively include themselves.
+	maxIncludeDepth = 100
+)
+
+// parsePartials loads the partials found within the template paths into the
+// passed template set, allowing the job template to use any templates they
+// define. Each template path can either be a directory, from which all
+// partial files are loaded, or a glob pattern. Each partial is also available
+// as a template named after its file name.
+func (t *tmpl) parsePartials(tmpl *template.Template) error {
+
+	files, err := partialFiles(t.templatePaths)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tmpl.New(filepath.Base(file)).Parse(string(src)); err != nil {
+			return fmt.Errorf("unable to parse partial %s: %v", file, err)
+		}
+		log.Debug().Msgf("template/partials: loaded partial %s", file)
+	}
+	return nil
+}
+
+// partialFiles returns the partial files matched by the template paths, in
+// the order the paths were passed so that later paths can redefine templates
+// from earlier paths.
+func partialFiles(templatePaths []string) ([]string, error) {
+
+	var files []string
+
+	for _, p := range templatePaths {
+		pattern := p
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			pattern = filepath.Join(p, "*"+partialExtension)
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template path %s: %v", p, err)
+		}
+		if len(matches) == 0 {
+			log.Warn().Msgf("template/partials: no partials found in template path %s", p)
+		}
+
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// includeFuncs returns the include and tpl functions which render templates
+// from within the passed template set. Unlike the template action, these
+// return the rendered output as a string so it can be piped to other
+// functions such as indent.
+func includeFuncs(tmpl *template.Template) template.FuncMap {
+
+	var depth int
+
+	enter := func() error {
+		if depth >= maxIncludeDepth {
+			return fmt.Errorf("exceeded maximum include depth of %v", maxIncludeDepth)
+		}
+		depth++
+		return nil
+	}
+
+	return template.FuncMap{
+
+		// include renders the named template with the passed data.
+		"include": func(name string, data interface{}) (string, error) {
+			if err := enter(); err != nil {
+				return "", err
+			}
+			defer func() { depth-- }()
+
+			var buf bytes.Buffer
+			if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+				return "", err
+			}
+			return buf.String(), nil
+		},
+
+		// tpl renders the passed string as a template with the passed data,
+		// with access to all functions and partials of the job template.
+		"tpl": func(text string, data interface{}) (string, error) {
+			if err := enter(); err != nil {
+				return "", err
+			}
+			defer func() { depth-- }()
+
+			clone, err := tmpl.Clone()
+			if err != nil {
+				return "", err
+			}
+
+			t, err := clone.New("tpl").Parse(text)
+			if err != nil {
+				return "", err
+			}
+
+			var buf bytes.Buffer
+			if err := t.Execute(&buf, data); err != nil {
+				return "", err
+			}
+			return buf.String(), nil
+		},
+	}
+}
@@ -0,0 +1,405 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"github.com/hashicorp/levant/helper"
+	"github.com/hashicorp/levant/levant/structs"
+	"github.com/rs/zerolog/log"
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// The types of source a variable value can be set from.
+const (
+	SourceDefaultFile   = "default-file"
+	SourceFile          = "file"
+	SourceFlag          = "flag"
+	SourceRemote        = "remote"
+	SourceSchemaDefault = "schema-default"
+	SourceUnset         = "unset"
+)
+
+// VariableSource describes where the value of a variable was set.
+type VariableSource struct {
+	Type string `json:"type" yaml:"type"`
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	Line int    `json:"line,omitempty" yaml:"line,omitempty"`
+}
+
+func (s *VariableSource) String() string {
+
+	var out string
+
+	switch s.Type {
+	case SourceFlag:
+		return "command line flag"
+	case SourceUnset:
+		return "referenced by template but not set"
+	case SourceDefaultFile:
+		out = "default file " + s.Name
+	case SourceRemote:
+		out = "remote " + s.Name
+	case SourceSchemaDefault:
+		out = "schema default " + s.Name
+	default:
+		out = "file " + s.Name
+	}
+
+	if s.Line > 0 {
+		out = fmt.Sprintf("%s:%v", out, s.Line)
+	}
+	return out
+}
+
+// ResolvedVariable is a single leaf value within the resolved variables.
+type ResolvedVariable struct {
+	Path      string
+	Value     interface{}
+	Source    *VariableSource
+	Sensitive bool
+}
+
+// ResolveVariables builds the final set of variables which would be used to
+// render the template, returning every leaf value along with its source.
+// Variables referenced by the template which have not been set are included
+// with an unset source.
+func ResolveVariables(config *structs.TemplateConfig, clientConfig *structs.ClientConfig, flagVars *map[string]interface{}) ([]*ResolvedVariable, error) {
+
+	t, err := newTmpl(config, clientConfig, flagVars)
+	if err != nil {
+		return nil, err
+	}
+
+	variables, err := t.resolveVariables(config.VariableSchemaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*ResolvedVariable
+
+	for p, value := range flattenVariables(variables, "") {
+		out = append(out, &ResolvedVariable{
+			Path:      p,
+			Value:     normalizeVariable(value),
+			Source:    t.variableSource(p),
+			Sensitive: t.sensitiveVariable(p),
+		})
+	}
+
+	if t.jobTemplateFile != "" {
+		src, err := os.ReadFile(t.jobTemplateFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tmpl, err := t.newTemplate().Parse(string(src))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ref := range templateReferences(tmpl.Tree.Root) {
+			if _, ok := variables[ref]; !ok {
+				out = append(out, &ResolvedVariable{Path: ref, Source: &VariableSource{Type: SourceUnset}})
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+
+	return out, nil
+}
+
+// variableFileSource returns the source of the passed variable file along
+// with the line number each variable was set on, where it can be determined.
+func (t *tmpl) variableFileSource(variableFile string) (*VariableSource, map[string]int) {
+
+	for _, scheme := range []string{nomadVarScheme, consulScheme, httpScheme, httpsScheme} {
+		if strings.HasPrefix(variableFile, scheme) {
+			return &VariableSource{Type: SourceRemote, Name: variableFile}, nil
+		}
+	}
+
+	src := &VariableSource{Type: SourceFile, Name: variableFile}
+	if t.defaultVariableFile {
+		src.Type = SourceDefaultFile
+	}
+
+	var lines map[string]int
+	var err error
+
+	switch path.Ext(variableFile) {
+	case terraformVarExtension:
+		lines = make(map[string]int)
+		for name, decl := range t.variableDeclarations {
+			if decl.Source == variableFile {
+				lines[name] = decl.Line
+			}
+		}
+	case yamlVarExtension, ymlVarExtension, jsonVarExtension:
+		var b []byte
+		if b, err = os.ReadFile(variableFile); err != nil {
+			break
+		}
+		if path.Ext(variableFile) == jsonVarExtension {
+			lines, err = jsonVariableLines(b)
+		} else {
+			lines, err = yamlVariableLines(b)
+		}
+	}
+
+	// Line numbers are only used to report where variables were set, so
+	// failing to determine them is not an error.
+	if err != nil {
+		log.Debug().Err(err).Msgf("template/provenance: unable to determine line numbers of %s", variableFile)
+	}
+	return src, lines
+}
+
+// recordVariableSources records the source of every leaf value within the
+// passed variables, replacing the source of any earlier value.
+func (t *tmpl) recordVariableSources(variables map[string]interface{}, src *VariableSource, lines map[string]int) {
+
+	if t.variableSources == nil {
+		t.variableSources = make(map[string]*VariableSource)
+	}
+
+	for p := range flattenVariables(variables, "") {
+		leafSrc := *src
+		for key := p; key != "" && lines != nil; key = parentVariablePath(key) {
+			if line, ok := lines[key]; ok {
+				leafSrc.Line = line
+				break
+			}
+		}
+		t.variableSources[p] = &leafSrc
+	}
+}
+
+// variableSource returns the recorded source of the variable at the passed
+// path, falling back to the source of its closest parent.
+func (t *tmpl) variableSource(p string) *VariableSource {
+	for key := p; key != ""; key = parentVariablePath(key) {
+		if src, ok := t.variableSources[key]; ok {
+			return src
+		}
+	}
+	return &VariableSource{Type: SourceUnset}
+}
+
+// sensitiveVariable returns whether the variable at the passed path is
+// sensitive, either because the top level variable containing it has been
+// declared as sensitive or any part of the path matches a sensitive name
+// pattern.
+func (t *tmpl) sensitiveVariable(p string) bool {
+	keys := strings.Split(p, ".")
+
+	if decl, ok := t.variableDeclarations[keys[0]]; ok && decl.Sensitive {
+		return true
+	}
+
+	for _, key := range keys {
+		if helper.IsSensitiveName(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenVariables returns every leaf value within the variables keyed by its
+// dotted path. Lists and empty maps are treated as leaf values.
+func flattenVariables(variables map[string]interface{}, prefix string) map[string]interface{} {
+
+	out := make(map[string]interface{})
+
+	for k, v := range variables {
+		p := joinVariablePath(prefix, k)
+
+		if m, ok := normalizeVariable(v).(map[string]interface{}); ok && len(m) > 0 {
+			for leaf, value := range flattenVariables(m, p) {
+				out[leaf] = value
+			}
+			continue
+		}
+		out[p] = v
+	}
+	return out
+}
+
+func joinVariablePath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func parentVariablePath(p string) string {
+	if i := strings.LastIndex(p, "."); i != -1 {
+		return p[:i]
+	}
+	return ""
+}
+
+// yamlVariableLines returns the line number each key within a YAML document
+// is declared on, keyed by the dotted path of the key.
+func yamlVariableLines(src []byte) (map[string]int, error) {
+
+	var root yaml3.Node
+	if err := yaml3.Unmarshal(src, &root); err != nil {
+		return nil, err
+	}
+
+	lines := make(map[string]int)
+	if len(root.Content) > 0 {
+		walkYAMLLines(root.Content[0], "", lines)
+	}
+	return lines, nil
+}
+
+func walkYAMLLines(n *yaml3.Node, prefix string, lines map[string]int) {
+	if n.Kind != yaml3.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		p := joinVariablePath(prefix, key.Value)
+		lines[p] = key.Line
+		walkYAMLLines(value, p, lines)
+	}
+}
+
+// jsonVariableLines returns the line number each key within a JSON object is
+// declared on, keyed by the dotted path of the key.
+func jsonVariableLines(src []byte) (map[string]int, error) {
+
+	dec := json.NewDecoder(bytes.NewReader(src))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected JSON object")
+	}
+
+	lines := make(map[string]int)
+	if err := walkJSONLines(dec, src, "", lines); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+func walkJSONLines(dec *json.Decoder, src []byte, prefix string, lines map[string]int) error {
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		p := joinVariablePath(prefix, fmt.Sprint(tok))
+		lines[p] = bytes.Count(src[:dec.InputOffset()], []byte("\n")) + 1
+
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			if err := walkJSONLines(dec, src, p, lines); err != nil {
+				return err
+			}
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+		case json.Delim('['):
+			for depth := 1; depth > 0; {
+				if tok, err = dec.Token(); err != nil {
+					return err
+				}
+				switch tok {
+				case json.Delim('['), json.Delim('{'):
+					depth++
+				case json.Delim(']'), json.Delim('}'):
+					depth--
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// templateReferences returns the names of the top level variables referenced
+// by the template. References within range and with blocks are only included
+// when made through the root variable, as the dot refers to another value.
+func templateReferences(root *parse.ListNode) []string {
+
+	refs := make(map[string]struct{})
+	walkTemplateNode(root, true, refs)
+
+	out := make([]string, 0, len(refs))
+	for ref := range refs {
+		out = append(out, ref)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func walkTemplateNode(node parse.Node, rootScope bool, refs map[string]struct{}) {
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			walkTemplateNode(c, rootScope, refs)
+		}
+	case *parse.ActionNode:
+		walkTemplateNode(n.Pipe, rootScope, refs)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			walkTemplateNode(c, rootScope, refs)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkTemplateNode(arg, rootScope, refs)
+		}
+	case *parse.ChainNode:
+		walkTemplateNode(n.Node, rootScope, refs)
+	case *parse.FieldNode:
+		if rootScope {
+			refs[n.Ident[0]] = struct{}{}
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			refs[n.Ident[1]] = struct{}{}
+		}
+	case *parse.IfNode:
+		walkTemplateNode(n.Pipe, rootScope, refs)
+		walkTemplateNode(n.List, rootScope, refs)
+		walkTemplateNode(n.ElseList, rootScope, refs)
+	case *parse.RangeNode:
+		walkTemplateNode(n.Pipe, rootScope, refs)
+		walkTemplateNode(n.List, false, refs)
+		walkTemplateNode(n.ElseList, rootScope, refs)
+	case *parse.WithNode:
+		walkTemplateNode(n.Pipe, rootScope, refs)
+		walkTemplateNode(n.List, false, refs)
+		walkTemplateNode(n.ElseList, rootScope, refs)
+	case *parse.TemplateNode:
+		walkTemplateNode(n.Pipe, rootScope, refs)
+	}
+}
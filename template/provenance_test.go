@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/levant/levant/structs"
+)
+
+func TestProvenance_ResolveVariables(t *testing.T) {
+
+	config := &structs.TemplateConfig{
+		TemplateFile:       "test-fixtures/missing_var.nomad",
+		VariableFiles:      []string{"test-fixtures/test-nested.json", "test-fixtures/test-overwrite.yaml"},
+		VariableSchemaFile: "test-fixtures/schema.yaml",
+	}
+	fVars := map[string]interface{}{"resources": map[string]interface{}{"cpu": "1000"}}
+
+	vars, err := ResolveVariables(config, &structs.ClientConfig{}, &fVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]VariableSource{
+		"binary_url":        {Type: SourceUnset},
+		"datacenters":       {Type: SourceFile, Name: "test-fixtures/test-nested.json", Line: 7},
+		"job_name":          {Type: SourceFile, Name: "test-fixtures/test-overwrite.yaml", Line: 4},
+		"resources.cpu":     {Type: SourceFlag},
+		"resources.memory":  {Type: SourceFile, Name: "test-fixtures/test-nested.json", Line: 5},
+		"task_resource_cpu": {Type: SourceSchemaDefault, Name: "test-fixtures/schema.yaml", Line: 8},
+	}
+
+	actual := make(map[string]VariableSource)
+	for _, v := range vars {
+		actual[v.Path] = *v.Source
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected \n%#v\n\n, got \n\n%#v\n\n", expected, actual)
+	}
+}
+
+func TestProvenance_templateReferences(t *testing.T) {
+
+	src := `[[ .job_name ]] [[ range .datacenters ]][[ .name ]][[ $.region ]][[ end ]]
+[[ with .resources ]][[ .cpu ]][[ else ]][[ .default_cpu ]][[ end ]][[ if .enabled ]][[ .nested.value ]][[ end ]]`
+
+	tmpl, err := (&tmpl{}).newTemplate().Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"datacenters", "default_cpu", "enabled", "job_name", "nested", "region", "resources"}
+	if actual := templateReferences(tmpl.Tree.Root); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
@@ -9,11 +9,11 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 
-	"github.com/hashicorp/levant/client"
 	"github.com/hashicorp/levant/helper"
+	"github.com/hashicorp/levant/levant/structs"
 	nomad "github.com/hashicorp/nomad/api"
-	"github.com/hashicorp/nomad/jobspec2"
 	"github.com/hashicorp/terraform/configs"
 	"github.com/hashicorp/terraform/configs/hcl2shim"
 	"github.com/rs/zerolog/log"
@@ -23,71 +23,218 @@ import (
 // RenderJob takes in a template and variables performing a render of the
 // template followed by Nomad jobspec parse.
 func RenderJob(templateFile string, variableFiles []string, addr string, flagVars *map[string]interface{}) (job *nomad.Job, err error) {
-	var tpl *bytes.Buffer
-	tpl, err = RenderTemplate(templateFile, variableFiles, addr, flagVars)
+	return RenderJobWithConfig(&structs.TemplateConfig{TemplateFile: templateFile, VariableFiles: variableFiles},
+		&structs.ClientConfig{ConsulAddr: addr}, flagVars)
+}
+
+// RenderJobWithConfig takes in the template and client configuration performing
+// a render of the template followed by Nomad jobspec parse.
+func RenderJobWithConfig(config *structs.TemplateConfig, clientConfig *structs.ClientConfig, flagVars *map[string]interface{}) (job *nomad.Job, err error) {
+	_, job, err = RenderWithConfig(config, clientConfig, flagVars)
+	return
+}
+
+// RenderWithConfig renders the template and parses the result into a Nomad
+// job, returning both the rendered template and the job.
+func RenderWithConfig(config *structs.TemplateConfig, clientConfig *structs.ClientConfig, flagVars *map[string]interface{}) (tpl *bytes.Buffer, job *nomad.Job, err error) {
+
+	t, err := newTmpl(config, clientConfig, flagVars)
+	if err != nil {
+		return
+	}
+	return t.renderJob(config)
+}
+
+// RenderWithDataSources renders the template and parses the result into a
+// Nomad job, using the passed data sources in place of those registered of
+// the same name. This allows fakes to be used for any external lookups.
+func RenderWithDataSources(config *structs.TemplateConfig, clientConfig *structs.ClientConfig, sources map[string]DataSource, flagVars *map[string]interface{}) (tpl *bytes.Buffer, job *nomad.Job, err error) {
+
+	t, err := initTmpl(config, flagVars)
+	if err != nil {
+		return
+	}
+	t.sources = newDataSources(clientConfig, sources, false)
+
+	return t.renderJob(config)
+}
+
+// RenderWithFixtures renders the template and parses the result into a Nomad
+// job, using the passed fixtures in place of all external lookups so that no
+// network calls are made.
+func RenderWithFixtures(config *structs.TemplateConfig, fixtures *Fixtures, flagVars *map[string]interface{}) (tpl *bytes.Buffer, job *nomad.Job, err error) {
+
+	t, err := initTmpl(config, flagVars)
+	if err != nil {
+		return
+	}
+
+	if fixtures == nil {
+		fixtures = &Fixtures{}
+	}
+	t.sources = newDataSources(nil, fixtures.dataSources(), true)
+
+	return t.renderJob(config)
+}
+
+// renderJob renders the template and parses the result into a Nomad job.
+func (t *tmpl) renderJob(config *structs.TemplateConfig) (tpl *bytes.Buffer, job *nomad.Job, err error) {
+
+	tpl, variables, err := t.render(config.VariableSchemaFile)
 	if err != nil {
 		return
 	}
 
-	return jobspec2.Parse("", tpl)
+	environ, err := t.environ()
+	if err != nil {
+		return
+	}
+
+	job, err = parseJob(config.TemplateFile, config.JobFormat, tpl, variables, environ)
+	return
 }
 
 // RenderTemplate is the main entry point to render the template based on the
 // passed variables file.
 func RenderTemplate(templateFile string, variableFiles []string, addr string, flagVars *map[string]interface{}) (tpl *bytes.Buffer, err error) {
+	return RenderTemplateWithConfig(&structs.TemplateConfig{TemplateFile: templateFile, VariableFiles: variableFiles},
+		&structs.ClientConfig{ConsulAddr: addr}, flagVars)
+}
 
-	t := &tmpl{}
-	t.flagVariables = flagVars
-	t.jobTemplateFile = templateFile
-	t.variableFiles = variableFiles
+// RenderTemplateWithConfig renders the template based on the passed template
+// and client configuration.
+func RenderTemplateWithConfig(config *structs.TemplateConfig, clientConfig *structs.ClientConfig, flagVars *map[string]interface{}) (tpl *bytes.Buffer, err error) {
+	t, err := newTmpl(config, clientConfig, flagVars)
+	if err != nil {
+		return
+	}
+
+	tpl, _, err = t.render(config.VariableSchemaFile)
+	return
+}
 
-	c, err := client.NewConsulClient(addr)
+// render renders the template, also returning the resolved variables the
+// template was rendered with.
+func (t *tmpl) render(schemaFile string) (tpl *bytes.Buffer, variables map[string]interface{}, err error) {
+
+	variables, err = t.resolveVariables(schemaFile)
 	if err != nil {
 		return
 	}
 
-	t.consulClient = c
+	src, err := os.ReadFile(t.jobTemplateFile)
+	if err != nil {
+		return
+	}
+
+	if tpl, err = t.renderTemplate(string(src), variables); err != nil {
+		return
+	}
+
+	if t.sources.lookups != nil {
+		err = t.sources.lookups.finish()
+	}
+
+	return
+}
+
+// newTmpl sets up the template renderer based on the passed template and
+// client configuration.
+func newTmpl(config *structs.TemplateConfig, clientConfig *structs.ClientConfig, flagVars *map[string]interface{}) (*tmpl, error) {
+
+	t, err := initTmpl(config, flagVars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case config.Offline:
+		sources, err := offlineDataSources(config.ConsulFixtureFile)
+		if err != nil {
+			return nil, err
+		}
+		t.sources = newDataSources(clientConfig, sources, true)
+		log.Debug().Msgf("template/render: rendering offline, external lookups are disabled")
+	case config.ConsulFixtureFile != "":
+		return nil, fmt.Errorf("consul fixture file %s can only be used when rendering offline", config.ConsulFixtureFile)
+	default:
+		t.sources = newDataSources(clientConfig, nil, false)
+	}
+
+	if config.Lock || config.Locked {
+		if config.Lock && config.Locked {
+			return nil, fmt.Errorf("lookups cannot be both recorded and replayed from a lockfile")
+		}
+
+		lockFile := config.LockFile
+		if lockFile == "" {
+			lockFile = DefaultLockFile
+		}
+		if t.sources.lookups, err = newLookupLock(lockFile, config.Locked); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// initTmpl sets up the template renderer based on the passed template
+// configuration, without the data sources used for external lookups.
+func initTmpl(config *structs.TemplateConfig, flagVars *map[string]interface{}) (*tmpl, error) {
+
+	t := &tmpl{}
+	t.flagVariables = flagVars
+	t.jobTemplateFile = config.TemplateFile
+	t.variableFiles = config.VariableFiles
+	t.listMergeStrategy = config.ListMergeStrategy
+	t.strictVariables = config.StrictVariables
+	t.templatePaths = config.TemplatePaths
+	t.variableSources = make(map[string]*VariableSource)
+
+	if err := helper.ValidateListMergeStrategy(t.listMergeStrategy); err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range config.SensitivePatterns {
+		if err := helper.AddSensitivePattern(pattern); err != nil {
+			return nil, err
+		}
+	}
 
-	if len(t.variableFiles) == 0 {
+	if len(t.variableFiles) == 0 && !config.NoDefaultVariableFile {
 		log.Debug().Msgf("template/render: no variable file passed, trying defaults")
 		if defaultVarFile := helper.GetDefaultVarFile(); defaultVarFile != "" {
 			t.variableFiles = []string{defaultVarFile}
+			t.defaultVariableFile = true
 			log.Debug().Msgf("template/render: found default variable file, using %s", defaultVarFile)
 		}
 	}
 
-	mergedVariables := make(map[string]interface{})
-	for _, variableFile := range t.variableFiles {
-		// Process the variable file extension and log DEBUG so the template can be
-		// correctly rendered.
-		var ext string
-		if ext = path.Ext(variableFile); ext != "" {
-			log.Debug().Msgf("template/render: variable file extension %s detected", ext)
-		}
+	return t, nil
+}
+
+// resolveVariables loads the variable schema and files, merging them along
+// with the command line variables to build the final set of variables used to
+// render the template. The source of each variable is recorded as it is
+// merged.
+func (t *tmpl) resolveVariables(schemaFile string) (map[string]interface{}, error) {
 
-		var variables map[string]interface{}
-		switch ext {
-		case terraformVarExtension:
-			variables, err = t.parseTFVars(variableFile)
-		case yamlVarExtension, ymlVarExtension:
-			variables, err = t.parseYAMLVars(variableFile)
-		case jsonVarExtension:
-			variables, err = t.parseJSONVars(variableFile)
-		default:
-			err = fmt.Errorf("variables file extension %v not supported", ext)
+	if schemaFile != "" {
+		if err := t.parseVariableSchema(schemaFile); err != nil {
+			return nil, err
 		}
+	}
 
+	mergedVariables := make(map[string]interface{})
+	for _, variableFile := range t.variableFiles {
+		variables, err := t.parseVariableFile(variableFile)
 		if err != nil {
-			return
-		}
-		for k, v := range variables {
-			mergedVariables[k] = v
+			return nil, err
 		}
-	}
+		mergedVariables = helper.MergeVariables(mergedVariables, variables, t.listMergeStrategy)
 
-	src, err := os.ReadFile(t.jobTemplateFile)
-	if err != nil {
-		return
+		src, lines := t.variableFileSource(variableFile)
+		t.recordVariableSources(variables, src, lines)
 	}
 
 	// If no command line variables are passed; log this as DEBUG to provide much
@@ -95,10 +242,65 @@ func RenderTemplate(templateFile string, variableFiles []string, addr string, fl
 	if len(*t.flagVariables) == 0 {
 		log.Debug().Msgf("template/render: no command line variables passed")
 	}
+	t.recordVariableSources(*t.flagVariables, &VariableSource{Type: SourceFlag}, nil)
 
-	tpl, err = t.renderTemplate(string(src), mergedVariables)
+	variables, err := t.applyVariableDeclarations(helper.VariableMerge(&mergedVariables, t.flagVariables))
+	if err != nil {
+		return nil, err
+	}
 
-	return
+	// Register the values of sensitive variables before rendering so they are
+	// masked in all further log output.
+	for p, v := range flattenVariables(variables, "") {
+		if t.sensitiveVariable(p) {
+			markSensitiveValue(v)
+		}
+	}
+
+	return variables, nil
+}
+
+// parseVariableFile loads the variables from the passed variable file source,
+// which can either be a local file or a remote source identified by its
+// scheme.
+func (t *tmpl) parseVariableFile(variableFile string) (map[string]interface{}, error) {
+
+	switch {
+	case strings.HasPrefix(variableFile, nomadVarScheme):
+		return t.parseNomadVars(strings.TrimPrefix(variableFile, nomadVarScheme))
+	case strings.HasPrefix(variableFile, consulKVScheme):
+		return t.parseConsulVars(strings.TrimPrefix(variableFile, consulKVScheme))
+	case strings.HasPrefix(variableFile, consulScheme):
+		return nil, fmt.Errorf("consul variables source %v not supported, must use %s<prefix>",
+			variableFile, consulKVScheme)
+	case strings.HasPrefix(variableFile, httpScheme), strings.HasPrefix(variableFile, httpsScheme):
+		return t.parseHTTPVars(variableFile)
+	}
+
+	return t.parseLocalVariableFile(variableFile)
+}
+
+// parseLocalVariableFile loads the variables from a file on disk, using the
+// file extension to determine the format.
+func (t *tmpl) parseLocalVariableFile(variableFile string) (map[string]interface{}, error) {
+
+	// Process the variable file extension and log DEBUG so the template can be
+	// correctly rendered.
+	var ext string
+	if ext = path.Ext(variableFile); ext != "" {
+		log.Debug().Msgf("template/render: variable file extension %s detected", ext)
+	}
+
+	switch ext {
+	case terraformVarExtension:
+		return t.parseTFVars(variableFile)
+	case yamlVarExtension, ymlVarExtension:
+		return t.parseYAMLVars(variableFile)
+	case jsonVarExtension:
+		return t.parseJSONVars(variableFile)
+	default:
+		return nil, fmt.Errorf("variables file extension %v not supported", ext)
+	}
 }
 
 func (t *tmpl) parseJSONVars(variableFile string) (variables map[string]interface{}, err error) {
@@ -130,6 +332,7 @@ func (t *tmpl) parseTFVars(variableFile string) (map[string]interface{}, error)
 	variables := make(map[string]interface{})
 	for _, variable := range loadedFile.Variables {
 		variables[variable.Name] = hcl2shim.ConfigValueFromHCL2(variable.Default)
+		t.declareVariable(tfVariableDeclaration(variable, variableFile))
 	}
 	return variables, nil
 }
@@ -154,18 +357,14 @@ func (t *tmpl) renderTemplate(src string, variables map[string]interface{}) (tpl
 
 	// Setup the template file for rendering
 	tmpl := t.newTemplate()
+	if err = t.parsePartials(tmpl); err != nil {
+		return
+	}
 	if tmpl, err = tmpl.Parse(src); err != nil {
 		return
 	}
 
-	if variables != nil {
-		// Merge variables passed on the CLI with those passed through a variables file.
-		err = tmpl.Execute(tpl, helper.VariableMerge(&variables, t.flagVariables))
-	} else {
-		// No variables file passed; render using any passed CLI variables.
-		log.Debug().Msgf("template/render: variable file not passed")
-		err = tmpl.Execute(tpl, t.flagVariables)
-	}
+	err = tmpl.Execute(tpl, variables)
 
 	return tpl, err
 }
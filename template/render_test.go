@@ -5,8 +5,11 @@ package template
 
 import (
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/levant/helper"
+	"github.com/hashicorp/levant/levant/structs"
 	nomad "github.com/hashicorp/nomad/api"
 )
 
@@ -119,3 +122,330 @@ func TestTemplater_RenderTemplate(t *testing.T) {
 		t.Fatalf("expected %s but got %v", testEnvValue, *job.TaskGroups[0].Name)
 	}
 }
+
+func TestTemplater_RenderTemplateVariableDeclarations(t *testing.T) {
+
+	// Command line variables are converted to the declared type.
+	fVars := map[string]interface{}{"task_resource_cpu": "500"}
+
+	tpl, err := RenderTemplate("test-fixtures/single_templated.nomad", []string{"test-fixtures/test-validation.tf"}, "", &fVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(tpl.String(), "cpu    = 500") {
+		t.Fatalf("expected rendered CPU resource of 500, got %s", tpl.String())
+	}
+
+	// Values which cannot be converted to the declared type, or fail the
+	// validation rules, are all reported.
+	fVars = map[string]interface{}{"task_resource_cpu": "10", "job_name": "aVeryLongJobNameIndeed"}
+
+	_, err = RenderTemplate("test-fixtures/single_templated.nomad", []string{"test-fixtures/test-validation.tf"}, "", &fVars)
+	if err == nil {
+		t.Fatal("expected validation error but got nil")
+	}
+	for _, msg := range []string{"must be at least 100", "must be at most 20 characters"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("expected error to contain %q, got %v", msg, err)
+		}
+	}
+
+	fVars = map[string]interface{}{"task_resource_cpu": "lots"}
+
+	_, err = RenderTemplate("test-fixtures/single_templated.nomad", []string{"test-fixtures/test-validation.tf"}, "", &fVars)
+	if err == nil || !strings.Contains(err.Error(), "invalid value for type number") {
+		t.Fatalf("expected type conversion error, got %v", err)
+	}
+}
+
+func TestTemplater_RenderTemplateVariableSchema(t *testing.T) {
+
+	config := &structs.TemplateConfig{
+		TemplateFile:       "test-fixtures/single_templated.nomad",
+		VariableSchemaFile: "test-fixtures/schema.yaml",
+	}
+
+	// Declared defaults are used for variables which have not been set.
+	fVars := map[string]interface{}{"job_name": testJobName}
+
+	job, err := RenderJobWithConfig(config, &structs.ClientConfig{}, &fVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *job.TaskGroups[0].Tasks[0].Resources.CPU != 500 {
+		t.Fatalf("expected CPU resource %v but got %v", 500, *job.TaskGroups[0].Tasks[0].Resources.CPU)
+	}
+
+	// Missing and invalid variables are reported together.
+	fVars = map[string]interface{}{"task_resource_cpu": "10"}
+
+	_, err = RenderJobWithConfig(config, &structs.ClientConfig{}, &fVars)
+	if err == nil {
+		t.Fatal("expected validation error but got nil")
+	}
+	for _, msg := range []string{"variable job_name: required variable not set", "must be at least 100"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Fatalf("expected error to contain %q, got %v", msg, err)
+		}
+	}
+}
+
+func TestTemplater_RenderTemplateStrictVariables(t *testing.T) {
+
+	config := &structs.TemplateConfig{
+		TemplateFile:    "test-fixtures/missing_var.nomad",
+		StrictVariables: true,
+	}
+
+	fVars := make(map[string]interface{})
+
+	if _, err := RenderTemplateWithConfig(config, &structs.ClientConfig{}, &fVars); err == nil {
+		t.Fatal("expected missing variable error but got nil")
+	}
+
+	fVars["job_name"] = testJobName
+	fVars["binary_url"] = "http://example.com/binary"
+
+	if _, err := RenderTemplateWithConfig(config, &structs.ClientConfig{}, &fVars); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTemplater_RenderTemplateSensitive(t *testing.T) {
+	t.Cleanup(helper.ResetSensitive)
+
+	fVars := map[string]interface{}{
+		"job_name":    testJobName,
+		"db_password": "s3cr3t-password",
+		"binary_url":  "http://example.com/s3cr3t-binary",
+		"api_token":   73310531,
+		"db_secret":   "60",
+	}
+
+	src := `job "[[ .job_name ]]" { meta { url = "[[ sensitive .binary_url ]]" } }`
+
+	tmp := t.TempDir() + "/sensitive.nomad"
+	if err := os.WriteFile(tmp, []byte(src), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tpl, err := RenderTemplate(tmp, nil, "", &fVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The rendered template contains the real values, while log output masks
+	// both the variable matching the sensitive name pattern and the value
+	// passed to the sensitive function.
+	if !strings.Contains(tpl.String(), "http://example.com/s3cr3t-binary") {
+		t.Fatalf("expected rendered template to contain the binary URL, got %s", tpl.String())
+	}
+	for _, secret := range []string{"s3cr3t-password", "http://example.com/s3cr3t-binary", "73310531"} {
+		if out := helper.Redact("value " + secret); out != "value "+helper.RedactedValue {
+			t.Fatalf("expected %s to be redacted, got %s", secret, out)
+		}
+	}
+
+	// Values too short to be masked safely are not redacted.
+	if out := helper.Redact("timeout 60s"); out != "timeout 60s" {
+		t.Fatalf("expected short value not to be redacted, got %s", out)
+	}
+}
+
+func TestTemplater_RenderTemplatePartials(t *testing.T) {
+
+	config := &structs.TemplateConfig{
+		TemplateFile:  "test-fixtures/partials.nomad",
+		TemplatePaths: []string{"test-fixtures/partials"},
+	}
+
+	fVars := map[string]interface{}{
+		"job_name":       testJobName,
+		"logging_image":  "fluent/fluent-bit:2.0",
+		"image_template": "redis:[[ .redis_version ]]",
+		"redis_version":  "7",
+	}
+
+	job, err := RenderJobWithConfig(config, &structs.ClientConfig{}, &fVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tasks := job.TaskGroups[0].Tasks
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks but got %v", len(tasks))
+	}
+	if image := tasks[0].Config["image"]; image != "redis:7" {
+		t.Fatalf("expected image %s but got %v", "redis:7", image)
+	}
+	if tasks[1].Name != "logging" || tasks[1].Config["image"] != "fluent/fluent-bit:2.0" {
+		t.Fatalf("expected logging task from partial but got %s with config %v", tasks[1].Name, tasks[1].Config)
+	}
+
+	// Rendering without the template path fails as the partial is not defined.
+	config.TemplatePaths = nil
+	if _, err = RenderJobWithConfig(config, &structs.ClientConfig{}, &fVars); err == nil {
+		t.Fatal("expected error rendering without partials but got nil")
+	}
+}
+
+func TestTemplater_RenderHCL2Variables(t *testing.T) {
+
+	config := &structs.TemplateConfig{
+		TemplateFile:      "test-fixtures/hcl2_variables.nomad",
+		ListMergeStrategy: helper.ListMergeReplace,
+	}
+	fVars := map[string]interface{}{
+		"job_name":    testJobName,
+		"group_count": "3",
+		"args":        []interface{}{"--dir", "${NOMAD_ALLOC_DIR}/data"},
+	}
+
+	job, err := RenderJobWithConfig(config, &structs.ClientConfig{}, &fVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *job.Name != testJobName {
+		t.Fatalf("expected %s but got %v", testJobName, *job.Name)
+	}
+	if *job.TaskGroups[0].Count != 3 {
+		t.Fatalf("expected count of 3 but got %v", *job.TaskGroups[0].Count)
+	}
+
+	args := job.TaskGroups[0].Tasks[0].Config["args"].([]interface{})
+	if len(args) != 2 || args[1] != "${NOMAD_ALLOC_DIR}/data" {
+		t.Fatalf("expected interpolation to be passed through but got %v", args)
+	}
+
+	// HCL2 variable defaults are used when Levant does not set a value.
+	delete(fVars, "group_count")
+	if job, err = RenderJobWithConfig(config, &structs.ClientConfig{}, &fVars); err != nil {
+		t.Fatal(err)
+	}
+	if *job.TaskGroups[0].Count != 1 {
+		t.Fatalf("expected default count of 1 but got %v", *job.TaskGroups[0].Count)
+	}
+
+	// Required HCL2 variables must be set.
+	delete(fVars, "args")
+	if _, err = RenderJobWithConfig(config, &structs.ClientConfig{}, &fVars); err == nil {
+		t.Fatal("expected error rendering without a required HCL2 variable")
+	}
+}
+
+func TestTemplater_RenderJSONJob(t *testing.T) {
+
+	config := &structs.TemplateConfig{
+		TemplateFile:      "test-fixtures/api_job.json",
+		ListMergeStrategy: helper.ListMergeReplace,
+	}
+	fVars := map[string]interface{}{"job_name": testJobName, "count": 2}
+
+	job, err := RenderJobWithConfig(config, &structs.ClientConfig{}, &fVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *job.ID != testJobName || *job.Name != testJobName {
+		t.Fatalf("expected ID and name %s but got %v and %v", testJobName, *job.ID, *job.Name)
+	}
+	if *job.TaskGroups[0].Count != 2 {
+		t.Fatalf("expected count of 2 but got %v", *job.TaskGroups[0].Count)
+	}
+
+	// The job can also be passed without the Job wrapper.
+	job, err = parseJSONJob([]byte(`{"Name": "example", "Type": "batch"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *job.ID != "example" || *job.Type != "batch" {
+		t.Fatalf("expected batch job example but got %v %v", *job.ID, *job.Type)
+	}
+
+	// Forcing the HCL format fails to parse the API JSON job.
+	config.JobFormat = JobFormatHCL
+	if _, err = RenderJobWithConfig(config, &structs.ClientConfig{}, &fVars); err == nil {
+		t.Fatal("expected error parsing API JSON job as HCL")
+	}
+}
+
+func TestTemplater_DetectJobFormat(t *testing.T) {
+
+	cases := map[string]string{
+		`job "example" {}`:                       JobFormatHCL,
+		`{"job": {"example": {}}}`:               JobFormatHCL,
+		`{"variable": {"count": {}}, "job": {}}`: JobFormatHCL,
+		`{"Job": {"ID": "example"}}`:             JobFormatJSON,
+		`{"ID": "example"}`:                      JobFormatJSON,
+	}
+
+	for src, expected := range cases {
+		if actual := detectJobFormat([]byte(src)); actual != expected {
+			t.Fatalf("expected format %s for %s but got %s", expected, src, actual)
+		}
+	}
+}
+
+func TestTemplater_RenderOffline(t *testing.T) {
+
+	fVars := make(map[string]interface{})
+	config := &structs.TemplateConfig{
+		TemplateFile:      "test-fixtures/consul_templated.nomad",
+		Offline:           true,
+		ConsulFixtureFile: "test-fixtures/consul_fixture.yaml",
+	}
+
+	// The Consul address is unreachable, so lookups must use the fixture.
+	clientConfig := &structs.ClientConfig{ConsulAddr: "127.0.0.1:1"}
+
+	job, err := RenderJobWithConfig(config, clientConfig, &fVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *job.ID != "cache" || *job.TaskGroups[0].Count != 3 {
+		t.Fatalf("expected job cache with count 3 but got %v %v", *job.ID, *job.TaskGroups[0].Count)
+	}
+	if image := job.TaskGroups[0].Tasks[0].Config["image"]; image != "redis:7" {
+		t.Fatalf("expected image redis:7 but got %v", image)
+	}
+
+	// Without a fixture the Consul lookups fail with a clear error.
+	config.ConsulFixtureFile = ""
+	_, err = RenderJobWithConfig(config, clientConfig, &fVars)
+	if err == nil || !strings.Contains(err.Error(), "data source consul is not available when rendering offline") {
+		t.Fatalf("expected offline error but got %v", err)
+	}
+
+	// A fixture can only be used when rendering offline.
+	config.Offline = false
+	config.ConsulFixtureFile = "test-fixtures/consul_fixture.yaml"
+	if _, err = RenderJobWithConfig(config, clientConfig, &fVars); err == nil {
+		t.Fatal("expected error using consul fixture when not offline")
+	}
+}
+
+func TestTemplater_RenderCreatesDataSourcesLazily(t *testing.T) {
+
+	var created int
+	RegisterDataSource(DataSourceConsul, func(_ *structs.ClientConfig) (DataSource, error) {
+		created++
+		return MapDataSource{"service/config/name": "cache", "service/image": "redis:7"}, nil
+	})
+	defer RegisterDataSource(DataSourceConsul, newConsulDataSource)
+
+	fVars := make(map[string]interface{})
+
+	if _, err := RenderJob("test-fixtures/none_templated.nomad", nil, "", &fVars); err != nil {
+		t.Fatal(err)
+	}
+	if created != 0 {
+		t.Fatalf("expected no Consul data source to be created but got %v", created)
+	}
+
+	if _, err := RenderJob("test-fixtures/consul_templated.nomad", nil, "", &fVars); err != nil {
+		t.Fatal(err)
+	}
+	if created != 1 {
+		t.Fatalf("expected a single Consul data source to be created but got %v", created)
+	}
+}
@@ -4,18 +4,36 @@
 package template
 
 import (
+	"fmt"
 	"text/template"
-
-	consul "github.com/hashicorp/consul/api"
 )
 
 // tmpl provides everything needed to fully render and job template using
 // inbuilt functions.
 type tmpl struct {
-	consulClient    *consul.Client
-	flagVariables   *map[string]interface{}
-	jobTemplateFile string
-	variableFiles   []string
+	cacheDir          string
+	flagVariables     *map[string]interface{}
+	jobTemplateFile   string
+	listMergeStrategy string
+	strictVariables   bool
+	templatePaths     []string
+	variableFiles     []string
+
+	// defaultVariableFile indicates the variable file was discovered in the
+	// working directory rather than passed by the user.
+	defaultVariableFile bool
+
+	// variableSources records where the value of each variable was set,
+	// keyed by the dotted path of the variable.
+	variableSources map[string]*VariableSource
+
+	// variableDeclarations holds the variables declared within the variable
+	// schema file and Terraform variable files, keyed by variable name.
+	variableDeclarations map[string]*variableDeclaration
+
+	// sources are the data sources read by template functions and remote
+	// variable files.
+	sources *DataSources
 }
 
 const (
@@ -25,13 +43,40 @@ const (
 	ymlVarExtension       = ".yml"
 	rightDelim            = "]]"
 	leftDelim             = "[["
+
+	consulScheme   = "consul://"
+	consulKVScheme = "consul://kv/"
+	nomadVarScheme = "nomadvar://"
+	httpScheme     = "http://"
+	httpsScheme    = "https://"
 )
 
 // newTemplate returns an empty template with default options set
 func (t *tmpl) newTemplate() *template.Template {
 	tmpl := template.New("jobTemplate")
 	tmpl.Delims(leftDelim, rightDelim)
-	tmpl.Option("missingkey=zero")
-	tmpl.Funcs(funcMap(t.consulClient))
+	if t.strictVariables {
+		tmpl.Option("missingkey=error")
+	} else {
+		tmpl.Option("missingkey=zero")
+	}
+	tmpl.Funcs(funcMap(t.sources))
+	tmpl.Funcs(includeFuncs(tmpl))
 	return tmpl
 }
+
+// environ returns the environment variables passed to the jobspec parser
+// from the environment data source.
+func (t *tmpl) environ() ([]string, error) {
+
+	values, err := t.sources.List(DataSourceEnv, "")
+	if err != nil {
+		return nil, err
+	}
+
+	env := make([]string, 0, len(values))
+	for _, k := range sortedKeys(values) {
+		env = append(env, fmt.Sprintf("%s=%v", k, values[k]))
+	}
+	return env, nil
+}
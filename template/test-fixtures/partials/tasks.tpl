@@ -0,0 +1,11 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: MPL-2.0
+
+[[- define "logging_task" ]]
+task "logging" {
+  driver = "docker"
+  config {
+    image = "[[ .logging_image ]]"
+  }
+}
+[[- end ]]
@@ -0,0 +1,21 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: MPL-2.0
+
+variable "job_name" {
+  default = "levantExample"
+
+  validation {
+    condition     = length(var.job_name) <= 20
+    error_message = "The job_name value must be at most 20 characters."
+  }
+}
+
+variable "task_resource_cpu" {
+  type    = number
+  default = 1313
+
+  validation {
+    condition     = var.task_resource_cpu >= 100
+    error_message = "The task_resource_cpu value must be at least 100."
+  }
+}
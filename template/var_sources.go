@@ -0,0 +1,305 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/levant/helper"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// checksumFragmentPrefix is the URL fragment prefix used to pin a remote
+	// variables file to a known SHA256 checksum.
+	checksumFragmentPrefix = "sha256="
+)
+
+// parseNomadVars loads the items of the Nomad Variable at the passed path as
+// variables. Item keys are split on the "." delimiter to build nested
+// variables in the same manner as command line variables.
+func (t *tmpl) parseNomadVars(varPath string) (map[string]interface{}, error) {
+
+	items, ok, err := getDataSourceValue[map[string]string](t.sources, DataSourceNomadVar, varPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("Nomad variable %s not found", varPath)
+	}
+
+	log.Debug().Msgf("template/var_sources: loaded %v items from Nomad variable %s", len(items), varPath)
+
+	return nomadItemsToVariables(items)
+}
+
+// parseConsulVars loads all keys under the passed Consul KV prefix as
+// variables. Keys are split on the "/" delimiter relative to the prefix to
+// build nested variables.
+func (t *tmpl) parseConsulVars(prefix string) (map[string]interface{}, error) {
+
+	// An empty prefix would list the entire KV store, loading every key within
+	// the cluster as a variable.
+	if strings.Trim(prefix, "/") == "" {
+		return nil, fmt.Errorf("Consul KV prefix must not be empty")
+	}
+
+	// List using the full path segment of the prefix, so that sibling keys such
+	// as "config/apple" are not returned when listing "config/app".
+	pairs, err := t.sources.List(DataSourceConsul, strings.TrimSuffix(prefix, "/")+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no Consul KV found under prefix %s", prefix)
+	}
+
+	log.Debug().Msgf("template/var_sources: loaded %v keys from Consul KV prefix %s", len(pairs), prefix)
+
+	return consulPairsToVariables(prefix, pairs)
+}
+
+// nomadItemsToVariables converts the flat items of a Nomad Variable into a
+// nested variables map.
+func nomadItemsToVariables(items map[string]string) (map[string]interface{}, error) {
+
+	variables := make(map[string]interface{})
+
+	// Sort the item keys so that any conflicts between simple and nested
+	// values are reported consistently.
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := helper.SetNestedVariable(variables, strings.Split(k, "."), items[k]); err != nil {
+			return nil, err
+		}
+	}
+	return variables, nil
+}
+
+// consulPairsToVariables converts the Consul KV pairs, keyed by their full
+// key, into a nested variables map relative to the prefix the pairs were
+// listed from.
+func consulPairsToVariables(prefix string, pairs map[string]interface{}) (map[string]interface{}, error) {
+
+	variables := make(map[string]interface{})
+
+	// Ensure we strip the full path segment of the prefix, so that listing
+	// "config/app" and "config/app/" results in the same variables.
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+
+	// Sort the keys so that any conflicts between simple and nested values are
+	// reported consistently.
+	for _, k := range sortedKeys(pairs) {
+		key, ok := strings.CutPrefix(k, prefix)
+
+		// Keys outside the prefix path, and keys ending in the delimiter which are
+		// folders within the KV store, do not hold values we want to use.
+		if !ok || key == "" || strings.HasSuffix(key, "/") {
+			continue
+		}
+
+		if err := helper.SetNestedVariable(variables, strings.Split(key, "/"), fmt.Sprint(pairs[k])); err != nil {
+			return nil, err
+		}
+	}
+	return variables, nil
+}
+
+// parseHTTPVars fetches a variables file over HTTP(S) and parses it based on
+// the URL extension or response content type. The URL may include a
+// "#sha256=<checksum>" fragment which the file contents must match. Fetched
+// files are cached on disk, so pinned files are used without making a request
+// and offline renders can use the cached copy.
+func (t *tmpl) parseHTTPVars(rawURL string) (map[string]interface{}, error) {
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var checksum string
+	if u.Fragment != "" {
+		if !strings.HasPrefix(u.Fragment, checksumFragmentPrefix) {
+			return nil, fmt.Errorf("variables file URL fragment %q not supported, must use %s<checksum>",
+				u.Fragment, checksumFragmentPrefix)
+		}
+		checksum = strings.ToLower(strings.TrimPrefix(u.Fragment, checksumFragmentPrefix))
+	}
+
+	u.Fragment = ""
+	source := u.String()
+
+	if t.cacheDir == "" {
+		if t.cacheDir, err = defaultCacheDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	cacheKey := sha256Hex([]byte(source))
+	cached := cachedVarFile(t.cacheDir, cacheKey)
+
+	// A pinned file can never change, so if we hold a cached copy which matches
+	// the checksum there is no need to fetch it again.
+	if checksum != "" && cached != "" {
+		if err := verifyVarFileChecksum(cached, checksum); err == nil {
+			log.Debug().Msgf("template/var_sources: using cached copy of pinned variables file %s", source)
+			return t.parseLocalVariableFile(cached)
+		}
+	}
+
+	// When rendering offline the file cannot be fetched, so an unpinned cached
+	// copy is used as requested. Otherwise a failed fetch is an error, so that
+	// a moved or removed file does not silently render stale values.
+	if _, err := t.sources.Source(DataSourceHTTP); err != nil {
+		if !t.sources.offline || cached == "" || checksum != "" {
+			return nil, err
+		}
+		log.Warn().Msgf("template/var_sources: rendering offline, using cached copy of variables file %s", source)
+		return t.parseLocalVariableFile(cached)
+	}
+
+	body, ext, err := t.fetchVarFile(u)
+	if err != nil {
+		return nil, err
+	}
+
+	if checksum != "" {
+		if sum := sha256Hex(body); sum != checksum {
+			return nil, fmt.Errorf("variables file %s has checksum %s, expected %s", source, sum, checksum)
+		}
+	}
+
+	if cached, err = writeCachedVarFile(t.cacheDir, cacheKey, ext, body); err != nil {
+		return nil, err
+	}
+
+	log.Debug().Msgf("template/var_sources: fetched variables file %s and cached at %s", source, cached)
+
+	return t.parseLocalVariableFile(cached)
+}
+
+// fetchVarFile downloads the variables file at the passed URL using the HTTP
+// data source, returning the contents along with the file extension which
+// identifies its format.
+func (t *tmpl) fetchVarFile(u *url.URL) ([]byte, string, error) {
+
+	content, ok, err := getDataSourceValue[HTTPContent](t.sources, DataSourceHTTP, u.String())
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !ok {
+		return nil, "", fmt.Errorf("unable to fetch variables file %s: not found", u)
+	}
+
+	ext := varFileExtension(u.Path, content.ContentType)
+	if ext == "" {
+		return nil, "", fmt.Errorf("unable to determine format of variables file %s", u)
+	}
+	return content.Body, ext, nil
+}
+
+// varFileExtension determines the variables file extension to use for a
+// remote file. The URL path extension is preferred, falling back to the
+// content type returned by the server.
+func varFileExtension(urlPath, contentType string) string {
+
+	switch ext := path.Ext(urlPath); ext {
+	case jsonVarExtension, terraformVarExtension, yamlVarExtension, ymlVarExtension:
+		return ext
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	switch mediaType {
+	case "application/json":
+		return jsonVarExtension
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return yamlVarExtension
+	case "application/hcl", "text/x-hcl":
+		return terraformVarExtension
+	default:
+		return ""
+	}
+}
+
+// defaultCacheDir returns the directory used to cache remote variables files
+// within the user cache directory.
+func defaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine variables file cache directory: %v", err)
+	}
+	return filepath.Join(dir, "levant", "var-files"), nil
+}
+
+// cachedVarFile returns the path of the cached copy of a remote variables
+// file, or an empty string if it has not been cached.
+func cachedVarFile(dir, key string) string {
+	matches, _ := filepath.Glob(filepath.Join(dir, key+".*"))
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[0]
+}
+
+// writeCachedVarFile writes the contents of a remote variables file to the
+// cache, replacing any previously cached copy.
+func writeCachedVarFile(dir, key, ext string, body []byte) (string, error) {
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	// The format of the remote file may have changed, so remove any cached copy
+	// which has a different extension.
+	if old := cachedVarFile(dir, key); old != "" {
+		if err := os.Remove(old); err != nil {
+			return "", err
+		}
+	}
+
+	p := filepath.Join(dir, key+ext)
+	if err := os.WriteFile(p, body, 0600); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// verifyVarFileChecksum checks that the SHA256 checksum of the file at the
+// passed path matches the expected value.
+func verifyVarFileChecksum(p, checksum string) error {
+	body, err := os.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	if sum := sha256Hex(body); sum != checksum {
+		return fmt.Errorf("has checksum %s, expected %s", sum, checksum)
+	}
+	return nil
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
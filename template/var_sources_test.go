@@ -0,0 +1,166 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarSources_nomadItemsToVariables(t *testing.T) {
+
+	items := map[string]string{
+		"job_name":         "levantExample",
+		"resources.cpu":    "1313",
+		"resources.memory": "256",
+	}
+
+	expected := map[string]interface{}{
+		"job_name": "levantExample",
+		"resources": map[string]interface{}{
+			"cpu":    "1313",
+			"memory": "256",
+		},
+	}
+
+	actual, err := nomadItemsToVariables(items)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	_, err = nomadItemsToVariables(map[string]string{"a": "1", "a.b": "2"})
+	require.Error(t, err)
+}
+
+func TestVarSources_consulPairsToVariables(t *testing.T) {
+
+	pairs := map[string]interface{}{
+		"config/app/":              "",
+		"config/app/job_name":      "levantExample",
+		"config/app/resources/":    "",
+		"config/app/resources/cpu": "1313",
+		"config/app":               "",
+		"config/apple/job_name":    "otherExample",
+	}
+
+	expected := map[string]interface{}{
+		"job_name": "levantExample",
+		"resources": map[string]interface{}{
+			"cpu": "1313",
+		},
+	}
+
+	for _, prefix := range []string{"config/app", "config/app/"} {
+		actual, err := consulPairsToVariables(prefix, pairs)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	}
+}
+
+func TestVarSources_parseConsulVarsEmptyPrefix(t *testing.T) {
+
+	tmpl := &tmpl{sources: newDataSources(nil, nil, false)}
+
+	for _, prefix := range []string{"", "/"} {
+		_, err := tmpl.parseConsulVars(prefix)
+		require.EqualError(t, err, "Consul KV prefix must not be empty")
+	}
+}
+
+func TestVarSources_parseHTTPVars(t *testing.T) {
+
+	body := []byte("job_name: levantExample\n")
+	checksum := sha256Hex(body)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/vars.yaml":
+			_, _ = w.Write(body)
+		case "/vars":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"job_name": "levantExample"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	tmpl := &tmpl{cacheDir: t.TempDir(), sources: newDataSources(nil, nil, false)}
+	expected := map[string]interface{}{"job_name": "levantExample"}
+
+	// Test a file using the extension and content type to find the format.
+	vars, err := tmpl.parseHTTPVars(srv.URL + "/vars.yaml")
+	require.NoError(t, err)
+	require.Equal(t, expected, vars)
+
+	vars, err = tmpl.parseHTTPVars(srv.URL + "/vars")
+	require.NoError(t, err)
+	require.Equal(t, expected, vars)
+
+	// Test checksum pinning.
+	vars, err = tmpl.parseHTTPVars(srv.URL + "/vars.yaml#sha256=" + checksum)
+	require.NoError(t, err)
+	require.Equal(t, expected, vars)
+
+	_, err = tmpl.parseHTTPVars(srv.URL + "/vars.yaml#sha256=" + sha256Hex([]byte("other")))
+	require.Error(t, err)
+
+	_, err = tmpl.parseHTTPVars(srv.URL + "/vars.yaml#md5=abc")
+	require.Error(t, err)
+
+	_, err = tmpl.parseHTTPVars(srv.URL + "/missing.yaml")
+	require.Error(t, err)
+
+	// Test a removed file is an error rather than using the cached copy.
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	_, err = tmpl.parseHTTPVars(srv.URL + "/vars.yaml")
+	require.Error(t, err)
+
+	// Test an unpinned cached copy is only used when rendering offline, while a
+	// pinned cached copy is always used.
+	srv.Close()
+
+	_, err = tmpl.parseHTTPVars(srv.URL + "/vars")
+	require.Error(t, err)
+
+	vars, err = tmpl.parseHTTPVars(srv.URL + "/vars.yaml#sha256=" + checksum)
+	require.NoError(t, err)
+	require.Equal(t, expected, vars)
+
+	tmpl.sources = newDataSources(nil, nil, true)
+
+	vars, err = tmpl.parseHTTPVars(srv.URL + "/vars")
+	require.NoError(t, err)
+	require.Equal(t, expected, vars)
+
+	vars, err = tmpl.parseHTTPVars(srv.URL + "/vars.yaml#sha256=" + checksum)
+	require.NoError(t, err)
+	require.Equal(t, expected, vars)
+
+	_, err = tmpl.parseHTTPVars(srv.URL + "/other.yaml")
+	require.Error(t, err)
+}
+
+func TestVarSources_varFileExtension(t *testing.T) {
+
+	cases := []struct {
+		Path        string
+		ContentType string
+		Expected    string
+	}{
+		{"/vars.yml", "text/plain", ".yml"},
+		{"/vars.tf", "", ".tf"},
+		{"/vars", "application/json; charset=utf-8", ".json"},
+		{"/vars", "application/x-yaml", ".yaml"},
+		{"/vars.txt", "text/plain", ""},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.Expected, varFileExtension(tc.Path, tc.ContentType))
+	}
+}
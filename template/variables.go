@@ -0,0 +1,387 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package template
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/levant/helper"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/configs/hcl2shim"
+	"github.com/rs/zerolog/log"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// variableDeclaration describes a variable the template expects, declared
+// either within a variable schema file or a Terraform variable file.
+type variableDeclaration struct {
+	Name        string
+	Description string
+	Type        cty.Type
+	Default     interface{}
+	Sensitive   bool
+	Validations []*variableValidation
+
+	// Source and Line identify where the variable was declared.
+	Source string
+	Line   int
+}
+
+// variableValidation is a rule the value of a variable must meet.
+type variableValidation struct {
+	Condition    hcl.Expression
+	ErrorMessage string
+}
+
+// variableSchema is the format of a variable schema file.
+type variableSchema struct {
+	Variables map[string]*variableSchemaEntry `yaml:"variables"`
+}
+
+type variableSchemaEntry struct {
+	Description string      `yaml:"description"`
+	Type        string      `yaml:"type"`
+	Default     interface{} `yaml:"default"`
+	Sensitive   bool        `yaml:"sensitive"`
+	Validation  []struct {
+		Condition    string `yaml:"condition"`
+		ErrorMessage string `yaml:"error_message"`
+	} `yaml:"validation"`
+}
+
+// parseVariableSchema loads the variable declarations from the passed schema
+// file. Terraform files are loaded using their variable blocks, all other
+// files are decoded as YAML, which also covers JSON.
+func (t *tmpl) parseVariableSchema(schemaFile string) error {
+
+	if path.Ext(schemaFile) == terraformVarExtension {
+		_, err := t.parseTFVars(schemaFile)
+		return err
+	}
+
+	src, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return err
+	}
+
+	var schema variableSchema
+	if err := yaml.Unmarshal(src, &schema); err != nil {
+		return fmt.Errorf("unable to parse variable schema file %s: %v", schemaFile, err)
+	}
+
+	// Line numbers are only used to report where variables were declared, so
+	// failing to determine them is not an error.
+	lines, err := yamlVariableLines(src)
+	if err != nil {
+		log.Debug().Err(err).Msgf("template/variables: unable to determine line numbers of %s", schemaFile)
+	}
+
+	var mErr multierror.Error
+
+	names := make([]string, 0, len(schema.Variables))
+	for name := range schema.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		decl, err := schema.Variables[name].declaration(name, schemaFile)
+		if err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("variable %s: %v", name, err))
+			continue
+		}
+		decl.Line = lines["variables."+name]
+		t.declareVariable(decl)
+	}
+
+	log.Debug().Msgf("template/variables: loaded %v variable declarations from %s", len(schema.Variables), schemaFile)
+
+	return mErr.ErrorOrNil()
+}
+
+// declaration converts a variable schema file entry into a declaration,
+// parsing the type and validation conditions as HCL expressions.
+func (e *variableSchemaEntry) declaration(name, source string) (*variableDeclaration, error) {
+
+	decl := &variableDeclaration{
+		Name:        name,
+		Description: e.Description,
+		Type:        cty.DynamicPseudoType,
+		Default:     e.Default,
+		Sensitive:   e.Sensitive,
+		Source:      source,
+	}
+
+	if e.Type != "" {
+		expr, diags := hclsyntax.ParseExpression([]byte(e.Type), source, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid type %q: %v", e.Type, diags)
+		}
+		ty, diags := typeexpr.TypeConstraint(expr)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid type %q: %v", e.Type, diags)
+		}
+		decl.Type = ty
+	}
+
+	for _, v := range e.Validation {
+		expr, diags := hclsyntax.ParseExpression([]byte(v.Condition), source, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid validation condition %q: %v", v.Condition, diags)
+		}
+		decl.Validations = append(decl.Validations, &variableValidation{
+			Condition:    expr,
+			ErrorMessage: v.ErrorMessage,
+		})
+	}
+	return decl, nil
+}
+
+// tfVariableDeclaration converts a Terraform variable block into a
+// declaration.
+func tfVariableDeclaration(v *configs.Variable, source string) *variableDeclaration {
+
+	decl := &variableDeclaration{
+		Name:        v.Name,
+		Description: v.Description,
+		Type:        v.Type,
+		Default:     hcl2shim.ConfigValueFromHCL2(v.Default),
+		Source:      source,
+		Line:        v.DeclRange.Start.Line,
+	}
+
+	for _, vv := range v.Validations {
+		decl.Validations = append(decl.Validations, &variableValidation{
+			Condition:    vv.Condition,
+			ErrorMessage: vv.ErrorMessage,
+		})
+	}
+	return decl
+}
+
+// declareVariable adds the declaration to the template, replacing any earlier
+// declaration of the same variable.
+func (t *tmpl) declareVariable(decl *variableDeclaration) {
+	if t.variableDeclarations == nil {
+		t.variableDeclarations = make(map[string]*variableDeclaration)
+	}
+	t.variableDeclarations[decl.Name] = decl
+}
+
+// applyVariableDeclarations validates the merged variables against the
+// declared variables before rendering. Declared defaults are used for any
+// variable which has not been set, values are converted to the declared type
+// and the declared validation rules are run. Every missing or invalid
+// variable is reported together so they can be fixed in a single pass.
+func (t *tmpl) applyVariableDeclarations(variables map[string]interface{}) (map[string]interface{}, error) {
+
+	if len(t.variableDeclarations) == 0 {
+		return variables, nil
+	}
+
+	out := make(map[string]interface{}, len(variables))
+	for k, v := range variables {
+		out[k] = v
+	}
+
+	var mErr multierror.Error
+
+	names := make([]string, 0, len(t.variableDeclarations))
+	for name := range t.variableDeclarations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		decl := t.variableDeclarations[name]
+
+		raw := out[name]
+		if raw == nil {
+			if decl.Default == nil {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("variable %s: required variable not set", name))
+				continue
+			}
+			raw = decl.Default
+			t.recordVariableSources(map[string]interface{}{name: raw},
+				&VariableSource{Type: SourceSchemaDefault, Name: decl.Source, Line: decl.Line}, nil)
+		}
+
+		val, err := typedVariableValue(raw, decl.Type)
+		if err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("variable %s: %v", name, err))
+			continue
+		}
+		out[name] = hcl2shim.ConfigValueFromHCL2(val)
+
+		for _, err := range validateVariable(decl, val) {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("variable %s: %v", name, err))
+		}
+	}
+
+	if err := mErr.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// markSensitiveValue registers every scalar within the passed value as
+// sensitive so that it is masked within log output. Values too short to be
+// masked without affecting unrelated output are skipped with a warning.
+func markSensitiveValue(v interface{}) {
+	switch typed := normalizeVariable(v).(type) {
+	case nil:
+	case []interface{}:
+		for _, item := range typed {
+			markSensitiveValue(item)
+		}
+	case map[string]interface{}:
+		for _, item := range typed {
+			markSensitiveValue(item)
+		}
+	default:
+		value := fmt.Sprint(typed)
+		if value != "" && len(value) < helper.MinSensitiveLength {
+			log.Warn().Msgf("template/variables: sensitive value shorter than %v characters will not be masked",
+				helper.MinSensitiveLength)
+			return
+		}
+		helper.MarkSensitive(value)
+	}
+}
+
+// typedVariableValue converts a variable value into the passed type.
+func typedVariableValue(raw interface{}, ty cty.Type) (cty.Value, error) {
+
+	val := hcl2shim.HCL2ValueFromConfigValue(normalizeVariable(raw))
+	if ty == cty.NilType || ty == cty.DynamicPseudoType {
+		return val, nil
+	}
+
+	converted, err := convert.Convert(val, ty)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("invalid value for type %s: %v", ty.FriendlyName(), err)
+	}
+	return converted, nil
+}
+
+// validateVariable evaluates each of the variable validation rules against
+// the passed value, returning an error for every rule which is not met.
+func validateVariable(decl *variableDeclaration, val cty.Value) []error {
+
+	var errs []error
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{decl.Name: val}),
+		},
+		Functions: validationFunctions(),
+	}
+
+	for _, v := range decl.Validations {
+		result, diags := v.Condition.Value(ctx)
+		if diags.HasErrors() {
+			errs = append(errs, fmt.Errorf("invalid validation condition: %v", diags))
+			continue
+		}
+
+		if result, err := convert.Convert(result, cty.Bool); err != nil || result.IsNull() || !result.IsKnown() {
+			errs = append(errs, fmt.Errorf("validation condition must return a boolean"))
+			continue
+		} else if result.False() {
+			errs = append(errs, fmt.Errorf("%s", v.ErrorMessage))
+		}
+	}
+	return errs
+}
+
+// normalizeVariable converts a variable value into a form which can be
+// represented as a cty value. YAML variable files decode nested maps with
+// interface keys which need converting to string keys.
+func normalizeVariable(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			out[fmt.Sprint(k)] = normalizeVariable(v)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			out[k] = normalizeVariable(v)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i, v := range typed {
+			out[i] = normalizeVariable(v)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// validationFunctions returns the functions available within variable
+// validation conditions. These are a subset of the Terraform functions.
+func validationFunctions() map[string]function.Function {
+	return map[string]function.Function{
+		"abs":        stdlib.AbsoluteFunc,
+		"can":        tryfunc.CanFunc,
+		"ceil":       stdlib.CeilFunc,
+		"coalesce":   stdlib.CoalesceFunc,
+		"concat":     stdlib.ConcatFunc,
+		"contains":   stdlib.ContainsFunc,
+		"floor":      stdlib.FloorFunc,
+		"format":     stdlib.FormatFunc,
+		"join":       stdlib.JoinFunc,
+		"keys":       stdlib.KeysFunc,
+		"length":     lengthFunc,
+		"lookup":     stdlib.LookupFunc,
+		"lower":      stdlib.LowerFunc,
+		"max":        stdlib.MaxFunc,
+		"min":        stdlib.MinFunc,
+		"regex":      stdlib.RegexFunc,
+		"regexall":   stdlib.RegexAllFunc,
+		"split":      stdlib.SplitFunc,
+		"substr":     stdlib.SubstrFunc,
+		"trimprefix": stdlib.TrimPrefixFunc,
+		"trimspace":  stdlib.TrimSpaceFunc,
+		"trimsuffix": stdlib.TrimSuffixFunc,
+		"try":        tryfunc.TryFunc,
+		"upper":      stdlib.UpperFunc,
+		"values":     stdlib.ValuesFunc,
+	}
+}
+
+// lengthFunc mirrors the Terraform length function which, unlike the cty
+// standard library version, also supports strings.
+var lengthFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name:             "value",
+			Type:             cty.DynamicPseudoType,
+			AllowDynamicType: true,
+		},
+	},
+	Type: function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if args[0].Type() == cty.String {
+			return stdlib.Strlen(args[0])
+		}
+		return stdlib.Length(args[0])
+	},
+})